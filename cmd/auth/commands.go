@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/moguchev/service/config"
+)
+
+const commandsUsage = `
+Commands:
+  config check	load and validate configuration, exit code is 1 if it is invalid
+`
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprint(flag.CommandLine.Output(), commandsUsage)
+}
+
+// runCommand runs command given after flags and returns exit code
+func runCommand(loader config.Loader, args []string) int {
+	switch strings.Join(args, " ") {
+	case "config check":
+		return checkConfig(loader)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", strings.Join(args, " "))
+		usage()
+		return 2
+	}
+}
+
+func checkConfig(loader config.Loader) int {
+	if _, err := loader.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("%s is valid\n", loader.Path)
+	return 0
+}
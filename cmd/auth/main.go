@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/moguchev/service/internal/app"
+	delivery "github.com/moguchev/service/internal/delivery/http"
+	repo "github.com/moguchev/service/internal/repository"
+	uc "github.com/moguchev/service/internal/usecase"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/config"
+	"github.com/moguchev/service/migration"
+	"github.com/moguchev/service/pkg/health"
+	"github.com/moguchev/service/pkg/password"
+	"github.com/moguchev/service/pkg/pgsql"
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.New()
+
+func main() {
+	loader := config.Loader{EnvPrefix: "AUTH"}
+	flag.StringVar(&loader.Path, "c", "auth.yaml", "set config path")
+	flag.Var(&loader.Overrides, "o", "override config field, e.g. -o server.address=:8000, may be repeated")
+	flag.Usage = usage
+	flag.Parse()
+
+	if loader.Path == "" {
+		log.WithError(fmt.Errorf("config path in blank")).Fatal("find config")
+	}
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(loader, flag.Args()))
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		// validation error lists problems line by line
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	a, err := app.New(loader, cfg, "auth")
+	if err != nil {
+		log.WithError(err).Fatal("init")
+	}
+	log = a.Log
+	ctx := a.Context()
+
+	// Create token manager and password hasher
+	if cfg.Token == nil {
+		log.Fatal("token config is required")
+	}
+
+	if err = cfg.Token.Validate(); err != nil {
+		log.WithError(err).Fatal("invalid token secret, set AUTH_TOKEN_SECRET or AUTH_TOKEN_SECRET_FILE")
+	}
+
+	tokens, err := cfg.Token.CreateManager()
+	if err != nil {
+		log.WithError(err).Fatal("init tokens")
+	}
+
+	if cfg.Password == nil {
+		cfg.Password = &password.Config{}
+	}
+
+	hasher, err := cfg.Password.CreateHasher()
+	if err != nil {
+		log.WithError(err).Fatal("init password hasher")
+	}
+
+	// Create DB
+	db, err := cfg.DB.CreateDB()
+	if err != nil {
+		log.WithError(err).Fatal("init db")
+	}
+
+	// Wait for DB, it may be still starting
+	if err = cfg.DB.Wait(ctx, db.PingContext); err != nil {
+		log.WithError(err).Fatal("wait db")
+	}
+
+	// Migrate DB
+	if err = cfg.DB.Migrate(ctx, db, migration.Assets); err != nil {
+		log.WithError(err).Fatal("migrate db")
+	}
+
+	// Create Repository level
+	userRepo := repo.NewUsersRepository(db)
+	txManager, err := cfg.DB.CreateTxManager(db)
+	if err != nil {
+		log.WithError(err).Fatal("init tx manager")
+	}
+	// Create Usecase level
+	userUC := uc.NewUsersUsecase(userRepo, txManager, tokens, hasher)
+
+	// Create Health checks
+	version, err := pgsql.LatestVersion(migration.Assets)
+	if err != nil {
+		log.WithError(err).Fatal("get latest migration version")
+	}
+
+	checker := health.New(cfg.Server.HealthTimeout)
+	checker.Add("db", db.PingContext)
+	checker.Add("migrations", func(ctx context.Context) error {
+		return pgsql.CheckVersion(ctx, db, version)
+	})
+
+	// Pool limits are reloadable
+	a.OnReload(func(cfg config.Config) {
+		db.SetMaxOpenConns(cfg.DB.MaxOpenConn)
+		db.SetMaxIdleConns(cfg.DB.MaxIdleConn)
+		db.SetConnMaxLifetime(cfg.DB.MaxConnLifetime)
+	})
+
+	// Create Router
+	router := mux.NewRouter()
+
+	// Set Handlers
+	router.HandleFunc("/healthz", checker.LiveHandler).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", checker.ReadyHandler).Methods(http.MethodGet)
+
+	base := router.PathPrefix(cfg.Server.APIBasePath).Subrouter()
+
+	delivery.SetUsersHandler(base, userUC)
+
+	// Make Server
+	srv := a.Server(cfg.Server.Address, a.Handler(router))
+
+	log.Infof("auth service started at %s", cfg.Server.Address)
+
+	if err = a.Run(checker, srv); err != nil {
+		os.Exit(1)
+	}
+}
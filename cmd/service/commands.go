@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v4"
+	"github.com/moguchev/service/config"
+	"github.com/moguchev/service/internal/seed"
+	"github.com/moguchev/service/migration"
+	"github.com/moguchev/service/pkg/pgsql"
+)
+
+// dateLayout - format of dates in command arguments
+const dateLayout = "2006-01-02"
+
+const commandsUsage = `
+Commands:
+  serve			run the service, default
+  config check		load and validate configuration, exit code is 1 if it is invalid
+  migrate up		apply all schema and extra migrations ordered by version
+  migrate down N	roll back N migrations
+  migrate goto V	migrate up or down to version V
+  migrate force V	set version V without running migrations, used to fix dirty database
+  migrate version	print current version
+  migrate -extra ...	run the same commands only on extra migrations from db.extra_migrations
+  migrate create [-dir migration/scripts] NAME
+			create empty up and down scripts, they are embedded on the next build
+  seed [-n 1000] [-seed 1] [-locale mix] [-until YYYY-MM-DD] [-out FILE] [-no-insert] [-truncate]
+			generate employees with salary histories and insert them with COPY,
+			the same seed and until date give the same data
+`
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprint(flag.CommandLine.Output(), commandsUsage)
+}
+
+func unknownCommand(args []string) int {
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", strings.Join(args, " "))
+	usage()
+	return 2
+}
+
+// runCommand runs command given after flags and returns exit code
+func runCommand(loader config.Loader, args []string) int {
+	switch {
+	case len(args) == 2 && args[0] == "config" && args[1] == "check":
+		return checkConfig(loader)
+	case len(args) > 1 && args[0] == "migrate":
+		return migrateCommand(loader, args[1:])
+	case len(args) > 0 && args[0] == "seed":
+		return seedCommand(loader, args[1:])
+	default:
+		return unknownCommand(args)
+	}
+}
+
+func checkConfig(loader config.Loader) int {
+	if _, err := loader.Load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("%s is valid\n", loader.Path)
+	return 0
+}
+
+func createMigration(args []string) int {
+	fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	dir := fs.String("dir", "migration/scripts", "directory of migration scripts")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		return unknownCommand(append([]string{"migrate", "create"}, args...))
+	}
+
+	up, down, err := pgsql.CreateMigration(*dir, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("created %s\ncreated %s\n", up, down)
+	return 0
+}
+
+// migrateArg parses numeric argument of migrate command
+func migrateArg(args []string) (int, error) {
+	if len(args) != 2 {
+		return 0, fmt.Errorf("%s expects one numeric argument", args[0])
+	}
+	return strconv.Atoi(args[1])
+}
+
+func migrateCommand(loader config.Loader, args []string) int {
+	if args[0] == "create" {
+		return createMigration(args[1:])
+	}
+
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	extra := flags.Bool("extra", false, "run command on extra migrations")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		return unknownCommand(append([]string{"migrate"}, args...))
+	}
+	args = flags.Args()
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	assets, table := fs.FS(migration.Assets), pgsql.SchemaMigrationsTable
+	if *extra {
+		if assets = cfg.DB.ExtraMigrationAssets(); assets == nil {
+			fmt.Fprintln(os.Stderr, "db.extra_migrations is empty")
+			return 1
+		}
+		table = pgsql.ExtraMigrationsTable
+	}
+
+	db, err := cfg.DB.CreateDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "init db:", err)
+		return 1
+	}
+	defer db.Close()
+
+	// migrations job may be started together with database
+	if err = cfg.DB.Wait(context.Background(), db.PingContext); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	m, err := cfg.DB.NewMigrator(assets, table)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "init migrator:", err)
+		return 1
+	}
+	defer m.Close()
+
+	// changes are serialized with replicas migrating on startup
+	locked := func(fn func() error) error {
+		return m.WithLock(context.Background(), cfg.DB.MigrationLockTimeout, fn)
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return unknownCommand(append([]string{"migrate"}, args...))
+		}
+		if *extra {
+			err = locked(m.Up)
+		} else {
+			// the same order as on startup, extra migrations are applied among schema ones
+			err = cfg.DB.EnsureDB(context.Background(), migration.Assets)
+		}
+	case "down":
+		var n int
+		if n, err = migrateArg(args); err == nil {
+			if n <= 0 {
+				err = fmt.Errorf("number of migrations must be positive, got %d", n)
+			} else {
+				err = locked(func() error { return m.Steps(-n) })
+			}
+		}
+	case "goto":
+		var v int
+		if v, err = migrateArg(args); err == nil {
+			if v <= 0 {
+				err = fmt.Errorf("version must be positive, got %d", v)
+			} else {
+				err = locked(func() error { return m.Migrate(uint(v)) })
+			}
+		}
+	case "force":
+		var v int
+		// -1 means no version, as in migrate tool
+		if v, err = migrateArg(args); err == nil {
+			err = locked(func() error { return m.Force(v) })
+		}
+	case "version":
+		if len(args) != 1 {
+			return unknownCommand(append([]string{"migrate"}, args...))
+		}
+	default:
+		return unknownCommand(append([]string{"migrate"}, args...))
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("no change")
+		err = nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s: %v\n", args[0], err)
+		return 1
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("no migrations applied")
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "get version:", err)
+		return 1
+	case dirty:
+		fmt.Printf("version %d (dirty)\n", version)
+	default:
+		fmt.Printf("version %d\n", version)
+	}
+
+	return 0
+}
+
+func seedCommand(loader config.Loader, args []string) int {
+	opts := seed.Options{}
+
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fs.IntVar(&opts.Employees, "n", 1000, "number of employees")
+	fs.Int64Var(&opts.Seed, "seed", 1, "random seed")
+	fs.StringVar(&opts.Locale, "locale", seed.Mix, "names and jobs locale (ru|en|mix)")
+	until := fs.String("until", time.Now().Format(dateLayout), "date of the latest assignment")
+	out := fs.String("out", "", "write generated data to JSON file")
+	noInsert := fs.Bool("no-insert", false, "do not insert data into database, only write -out file")
+	truncate := fs.Bool("truncate", false, "delete existing employees before insert")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		return unknownCommand(append([]string{"seed"}, args...))
+	}
+	if *noInsert && *out == "" {
+		fmt.Fprintln(os.Stderr, "-no-insert requires -out")
+		return 2
+	}
+
+	var err error
+	if opts.Until, err = time.Parse(dateLayout, *until); err != nil {
+		fmt.Fprintln(os.Stderr, "parse until:", err)
+		return 2
+	}
+
+	ctx := context.Background()
+
+	var conn *pgx.Conn
+	if !*noInsert {
+		cfg, err := loader.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		if conn, err = pgx.Connect(ctx, cfg.DB.Connection); err != nil {
+			fmt.Fprintln(os.Stderr, "connect db:", err)
+			return 1
+		}
+		defer conn.Close(ctx)
+
+		if !*truncate {
+			if opts.FirstEmployeeID, opts.FirstAssignmentID, err = seed.NextIDs(ctx, conn); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+
+	list, err := seed.Generate(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "generate:", err)
+		return 2
+	}
+
+	if *out != "" {
+		if err = seed.WriteFixtures(*out, list); err != nil {
+			fmt.Fprintln(os.Stderr, "write fixtures:", err)
+			return 1
+		}
+		fmt.Printf("written %d assignments to %s\n", len(list), *out)
+	}
+
+	if conn != nil {
+		if err = seed.Insert(ctx, conn, list, *truncate); err != nil {
+			fmt.Fprintln(os.Stderr, "insert:", err)
+			return 1
+		}
+		fmt.Printf("inserted %d employees with %d assignments\n", opts.Employees, len(list))
+	}
+
+	return 0
+}
@@ -2,125 +2,212 @@ package main
 
 import (
 	"context"
-	"errors"
 	"flag"
 	"fmt"
-	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"strings"
-	"syscall"
 
+	"github.com/moguchev/service/internal/app"
 	delivery "github.com/moguchev/service/internal/delivery/http"
+	"github.com/moguchev/service/internal/models"
 	repo "github.com/moguchev/service/internal/repository"
 	uc "github.com/moguchev/service/internal/usecase"
 
-	"github.com/golang-migrate/migrate"
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/moguchev/service/config"
 	"github.com/moguchev/service/migration"
+	"github.com/moguchev/service/pkg/admin"
+	"github.com/moguchev/service/pkg/buildinfo"
+	"github.com/moguchev/service/pkg/health"
 	"github.com/moguchev/service/pkg/logger"
-	"github.com/moguchev/service/pkg/middleware"
+	"github.com/moguchev/service/pkg/metrics"
 	"github.com/moguchev/service/pkg/pgsql"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sync/errgroup"
 )
 
 var log = logrus.New()
 
 func main() {
-	configPath := flag.String("c", "config.yaml", "set config path")
+	loader := config.Loader{EnvPrefix: "SERVICE"}
+	flag.StringVar(&loader.Path, "c", "config.yaml", "set config path")
+	flag.Var(&loader.Overrides, "o", "override config field, e.g. -o server.address=:8000, may be repeated")
+	flag.Usage = usage
 	flag.Parse()
 
-	if *configPath == "" {
+	if loader.Path == "" {
 		log.WithError(fmt.Errorf("config path in blank")).Fatal("find config")
 	}
 
-	cfg, err := config.GetConfig(*configPath)
-	if err != nil {
-		log.WithError(err).Fatal("create config")
+	if flag.NArg() > 0 && !(flag.NArg() == 1 && flag.Arg(0) == "serve") {
+		os.Exit(runCommand(loader, flag.Args()))
 	}
 
-	// Create logger
-	log = cfg.Log.CreateLogger()
+	cfg, err := loader.Load()
+	if err != nil {
+		// validation error lists problems line by line
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-	// Create ctx
-	ctx, cancel := context.WithCancel(context.Background())
-	ctx = logger.WithLogger(ctx, log)
+	a, err := app.New(loader, cfg, "service")
+	if err != nil {
+		log.WithError(err).Fatal("init")
+	}
+	log = a.Log
+	ctx := a.Context()
 
 	// Create DB
-	db, err := cfg.DB.CreateDB()
+	cluster, err := cfg.DB.CreateCluster()
 	if err != nil {
 		log.WithError(err).Fatal("init db")
 	}
+	db := cluster.Primary()
+	go cluster.Watch(ctx, cfg.DB.ReplicaCheckInterval)
+
+	// Wait for DB, it may be still starting
+	if err = cfg.DB.Wait(ctx, db.PingContext); err != nil {
+		log.WithError(err).Fatal("wait db")
+	}
 
 	// Migrate DB
-	err = pgsql.EnsureDB(db, migration.Assets)
-	// !errors.Is(err, migrate.ErrNoChange) do not work
-	if err != nil && strings.Compare(err.Error(), migrate.ErrNoChange.Error()) != 0 {
+	if err = cfg.DB.Migrate(ctx, db, migration.Assets); err != nil {
 		log.WithError(err).Fatal("migrate db")
 	}
 
 	// Create Repository level
-	empRepo := repo.NewEmployeesRepository(db)
+	empRepo := repo.NewEmployeesClusterRepository(cluster)
+
+	var pool *pgxpool.Pool
+	if cfg.DB.Backend == pgsql.BackendPgx {
+		if pool, err = cfg.DB.CreatePool(ctx); err != nil {
+			log.WithError(err).Fatal("init db pool")
+		}
+		defer pool.Close()
+
+		empRepo = repo.NewEmployeesPgxRepository(pool)
+	}
+
+	var breaker *pgsql.Breaker
+	if cfg.DB.Breaker != nil {
+		breaker = cfg.DB.Breaker.CreateBreaker()
+	}
+	if cfg.DB.Retry != nil || breaker != nil {
+		// breaker only
+		retry := pgsql.RetryConfig{MaxRetries: -1}
+		if cfg.DB.Retry != nil {
+			retry = *cfg.DB.Retry
+		}
+		empRepo = repo.NewEmployeesRetryRepository(empRepo, pgsql.NewRetrier(retry, breaker))
+	}
+
+	keyRepo := repo.NewAPIKeysRepository(db)
 	// Create Usecase level
 	empUC := uc.NewEmployeesUsecase(empRepo)
 
+	var adminKey string
+	if cfg.Auth != nil {
+		adminKey = cfg.Auth.AdminKey
+	}
+	keyUC := uc.NewAPIKeysUsecase(keyRepo, adminKey)
+
+	// Create Metrics
+	mtr := metrics.New("service")
+	if err = mtr.RegisterDB(db, "primary"); err != nil {
+		log.WithError(err).Fatal("init db metrics")
+	}
+	for name, rdb := range cluster.Replicas() {
+		if err = mtr.RegisterDB(rdb, name); err != nil {
+			log.WithError(err).Fatal("init db metrics")
+		}
+	}
+
+	// Create Health checks
+	version, err := pgsql.LatestVersion(migration.Assets)
+	if err != nil {
+		log.WithError(err).Fatal("get latest migration version")
+	}
+
+	checker := health.New(cfg.Server.HealthTimeout)
+	checker.Add("db", db.PingContext)
+	if pool != nil {
+		checker.Add("db_pool", pgsql.PingPool(pool))
+	}
+	if breaker != nil {
+		// readiness reports degraded while database is considered down,
+		// failing it would take all instances out of rotation at once
+		checker.AddDegraded("db_breaker", breaker.Check)
+	}
+	checker.Add("migrations", func(ctx context.Context) error {
+		return pgsql.CheckVersion(ctx, db, version)
+	})
+
+	// Pool limits are reloadable
+	a.OnReload(func(cfg config.Config) {
+		cluster.SetPool(*cfg.DB)
+	})
+
 	// Create Router
 	router := mux.NewRouter()
 
 	// Set Middlewares
-	mw := middleware.InitMiddleware(log)
-	router.Use(mw.RecoverMiddleware)
-	router.Use(mw.CORSMiddleware)
+	mw := a.MW
+	router.Use(mw.APIKeyMiddleware(keyUC))
+
+	if cfg.Token != nil {
+		if err = cfg.Token.Validate(); err != nil {
+			log.WithError(err).Fatal("invalid token secret, set SERVICE_TOKEN_SECRET or SERVICE_TOKEN_SECRET_FILE")
+		}
+
+		tokens, err := cfg.Token.CreateManager()
+		if err != nil {
+			log.WithError(err).Fatal("init tokens")
+		}
+		router.Use(mw.JWTMiddleware(tokens))
+	}
 
 	// Set Handlers
+	router.HandleFunc("/healthz", checker.LiveHandler).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", checker.ReadyHandler).Methods(http.MethodGet)
+
 	base := router.PathPrefix(cfg.Server.APIBasePath).Subrouter()
 
-	delivery.SetEmployeesHandler(base, empUC)
+	employeesAPI := base.NewRoute().Subrouter()
+	if cfg.Auth != nil && cfg.Auth.ProtectEmployees {
+		employeesAPI.Use(mw.RequireScope(models.ScopeEmployeesRead))
+	}
+
+	delivery.SetEmployeesHandler(employeesAPI, empUC)
+
+	adminAPI := base.PathPrefix("/admin").Subrouter()
+	adminAPI.Use(mw.RequireScope(models.ScopeAdmin))
+
+	delivery.SetAPIKeysHandler(adminAPI, keyUC)
+	adminAPI.HandleFunc("/log-level", logger.LevelHandler(log)).Methods(http.MethodGet, http.MethodPut)
 
 	// Make Server
-	bctx := logger.WithLogger(context.Background(), log)
-	srv := http.Server{
-		Handler: router,
-		Addr:    cfg.Server.Address,
-		BaseContext: func(net.Listener) context.Context {
-			return bctx
-		},
-	}
-
-	group, gctx := errgroup.WithContext(ctx)
-	group.Go(func() error {
-		err = srv.ListenAndServe()
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			return err
-		}
-		return nil
-	})
+	srv := a.Server(cfg.Server.Address, a.Handler(router, mtr.Middleware))
 
-	group.Go(func() error {
-		sgnl := make(chan os.Signal, 1)
-		signal.Notify(sgnl,
-			syscall.SIGHUP,
-			syscall.SIGINT,
-			syscall.SIGTERM,
-			syscall.SIGQUIT,
-		)
-		stop := <-sgnl
-		log.WithField("signal", stop).Info("waiting for all processes to stop")
-
-		cancel()
-		err = srv.Shutdown(gctx)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+	// Make Admin Server
+	adminRouter := admin.NewRouter()
+	adminRouter.Handle("/metrics", mtr.Handler()).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/log-level", logger.LevelHandler(log)).Methods(http.MethodGet, http.MethodPut)
 
-	log.Infof("service started at %s", cfg.Server.Address)
+	servers := []*http.Server{srv}
+	// admin server is stopped last to keep metrics available while draining
+	if cfg.Server.AdminAddress != "" {
+		servers = append(servers, a.Server(cfg.Server.AdminAddress, adminRouter))
+	}
+
+	log.WithFields(logrus.Fields{
+		"version": buildinfo.Version,
+		"commit":  buildinfo.Commit,
+	}).Infof("service started at %s", cfg.Server.Address)
+	if cfg.Server.AdminAddress != "" {
+		log.Infof("admin server started at %s", cfg.Server.AdminAddress)
+	}
 
-	if err = group.Wait(); err != nil {
-		log.WithError(err).Fatal()
+	if err = a.Run(checker, servers...); err != nil {
+		os.Exit(1)
 	}
 }
@@ -3,35 +3,111 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	logger "github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/middleware"
+	"github.com/moguchev/service/pkg/password"
 	"github.com/moguchev/service/pkg/pgsql"
+	"github.com/moguchev/service/pkg/token"
+	"github.com/moguchev/service/pkg/tracing"
 	"gopkg.in/yaml.v2"
 )
 
 type (
 	ServerConfig struct {
-		Address     string `yaml:"address"`
-		APIBasePath string `yaml:"basepath"`
+		Address     string `yaml:"address" json:"address" toml:"address"`
+		APIBasePath string `yaml:"basepath" json:"basepath" toml:"basepath"`
+		// AdminAddress - listener for /metrics, pprof, expvar, /version and /log-level, disabled if empty.
+		// It must not be reachable from outside.
+		AdminAddress string `yaml:"admin_address" json:"admin_address" toml:"admin_address"`
+		// HealthTimeout - timeout of each readiness check, health.DefaultTimeout if zero
+		HealthTimeout time.Duration `yaml:"health_timeout" json:"health_timeout" toml:"health_timeout"`
+	}
+
+	AuthConfig struct {
+		// AdminKey is accepted as an api key with admin scope, it is used to issue the first keys
+		AdminKey string `yaml:"admin_key" json:"admin_key" toml:"admin_key"`
+		// ProtectEmployees makes employees endpoints require employees:read scope,
+		// they are public by default
+		ProtectEmployees bool `yaml:"protect_employees" json:"protect_employees" toml:"protect_employees"`
 	}
 
 	Config struct {
-		Server *ServerConfig  `yaml:"server"`
-		DB     *pgsql.Config  `yaml:"db"`
-		Log    *logger.Config `yaml:"log"`
+		Server *ServerConfig  `yaml:"server" json:"server" toml:"server"`
+		DB     *pgsql.Config  `yaml:"db" json:"db" toml:"db"`
+		Log    *logger.Config `yaml:"log" json:"log" toml:"log"`
+		Auth   *AuthConfig    `yaml:"auth" json:"auth" toml:"auth"`
+		// CORS replaces middleware.DefaultCorsData if set
+		CORS      *middleware.CorsData        `yaml:"cors" json:"cors" toml:"cors"`
+		AccessLog *middleware.AccessLogConfig `yaml:"access_log" json:"access_log" toml:"access_log"`
+		// Token is required by the authorization service and enables bearer tokens in the service
+		Token *token.Config `yaml:"token" json:"token" toml:"token"`
+		// Password is used by the authorization service only
+		Password *password.Config `yaml:"password" json:"password" toml:"password"`
+		// Tracing is disabled if not set
+		Tracing *tracing.Config `yaml:"tracing" json:"tracing" toml:"tracing"`
+		// Reload - config file watching, see reloadable fields in reload.go
+		Reload *ReloadConfig `yaml:"reload" json:"reload" toml:"reload"`
 	}
 )
 
+// Loader - sources of configuration, each next one overrides previous:
+// yaml file, environment variables and flags
+type Loader struct {
+	Path string
+	// EnvPrefix - prefix of environment variables, e.g. SERVICE for SERVICE_DB_POSTGRESQL.
+	// Environment is not used if empty.
+	EnvPrefix string
+	Overrides Overrides
+}
+
+// Load reads configuration from all sources, sets defaults and validates it
+func (l Loader) Load() (Config, error) {
+	return l.load(os.LookupEnv)
+}
+
+func (l Loader) load(lookup func(string) (string, bool)) (Config, error) {
+	cfg, err := GetConfig(l.Path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if l.EnvPrefix != "" {
+		if err = applyEnv(&cfg, l.EnvPrefix, lookup); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err = applyOverrides(&cfg, l.Overrides); err != nil {
+		return Config{}, err
+	}
+
+	cfg.SetDefaults()
+
+	if err = cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// GetConfig reads configuration from file only, format is chosen by extension:
+// .yaml, .yml, .json or .toml. Unknown keys are rejected.
 func GetConfig(path string) (Config, error) {
 	var cfg Config
 
-	f, err := os.Open(path)
+	data, err := render(path)
 	if err != nil {
-		return Config{}, fmt.Errorf("open config file: %w", err)
+		return Config{}, err
 	}
 
-	err = yaml.NewDecoder(f).Decode(&cfg)
-	if err != nil {
+	// empty file is valid, everything may come from environment
+	if len(data) == 0 {
+		return cfg, nil
+	}
+
+	if err = yaml.UnmarshalStrict(data, &cfg); err != nil {
 		return Config{}, fmt.Errorf("decode: %w", err)
 	}
 
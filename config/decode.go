@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// includeKey - list of files the config extends, paths are relative to the config file.
+// Included files are merged in order and the including file is merged last.
+const includeKey = "include"
+
+// ErrIncludeCycle - config includes itself
+var ErrIncludeCycle = errors.New("include cycle")
+
+type tree = map[string]interface{}
+
+// parseFile decodes file to tree choosing format by extension
+func parseFile(path string) (tree, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open config file: %w", err)
+	}
+
+	t := tree{}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", "":
+		raw := map[interface{}]interface{}{}
+		if err = yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		t = normalize(raw).(tree)
+	case ".json":
+		if len(strings.TrimSpace(string(data))) == 0 {
+			break
+		}
+		if err = json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+	case ".toml":
+		if _, err = toml.Decode(string(data), &t); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", ext)
+	}
+
+	return t, nil
+}
+
+// normalize converts yaml maps to maps with string keys
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		t := make(tree, len(val))
+		for k, item := range val {
+			t[fmt.Sprint(k)] = normalize(item)
+		}
+		return t
+	case tree:
+		for k, item := range val {
+			val[k] = normalize(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalize(item)
+		}
+		return val
+	}
+	return v
+}
+
+// merge overlays src on dst: maps are merged recursively, other values are replaced
+func merge(dst, src tree) tree {
+	for k, v := range src {
+		if sv, ok := v.(tree); ok {
+			if dv, ok := dst[k].(tree); ok {
+				dst[k] = merge(dv, sv)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// load returns file merged over its includes
+func load(path string, visiting map[string]bool) (tree, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if visiting[abs] {
+		return nil, fmt.Errorf("%s: %w", path, ErrIncludeCycle)
+	}
+	visiting[abs] = true
+	defer delete(visiting, abs)
+
+	t, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	includes, err := includeList(t[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(t, includeKey)
+
+	merged := tree{}
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		base, err := load(inc, visiting)
+		if err != nil {
+			return nil, err
+		}
+		merged = merge(merged, base)
+	}
+
+	return merge(merged, t), nil
+}
+
+func includeList(v interface{}) ([]string, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("include: expected list of paths")
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("include: expected path or list of paths")
+}
+
+// render returns config file with includes resolved as single yaml document.
+// Every format is decoded through yaml to share strict decoding and duration parsing.
+func render(path string) ([]byte, error) {
+	t, err := load(path, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	if len(t) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(t)
+}
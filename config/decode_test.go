@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConfig_Formats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"config.yaml": `
+server:
+  address: ":7000"
+db:
+  postgresql: "user=leo"
+  max_open_conn: 20
+  max_conn_lifetime: 1h
+log:
+  levels: {repository: debug}
+cors:
+  allow_origins: ["https://a.com"]
+`,
+		"config.json": `{
+  "server": {"address": ":7000"},
+  "db": {"postgresql": "user=leo", "max_open_conn": 20, "max_conn_lifetime": "1h"},
+  "log": {"levels": {"repository": "debug"}},
+  "cors": {"allow_origins": ["https://a.com"]}
+}`,
+		"config.toml": `
+[server]
+address = ":7000"
+
+[db]
+postgresql = "user=leo"
+max_open_conn = 20
+max_conn_lifetime = "1h"
+
+[log.levels]
+repository = "debug"
+
+[cors]
+allow_origins = ["https://a.com"]
+`,
+	}
+
+	for name, content := range files {
+		cfg, err := GetConfig(writeFile(t, dir, name, content))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		assert.Equal(t, ":7000", cfg.Server.Address, name)
+		assert.Equal(t, "user=leo", cfg.DB.Connection, name)
+		assert.Equal(t, 20, cfg.DB.MaxOpenConn, name)
+		assert.Equal(t, time.Hour, cfg.DB.MaxConnLifetime, name)
+		assert.Equal(t, map[string]string{"repository": "debug"}, cfg.Log.Levels, name)
+		assert.Equal(t, []string{"https://a.com"}, cfg.CORS.AllowOrigins, name)
+	}
+
+	_, err = GetConfig(writeFile(t, dir, "unknown.json", `{"server": {"adress": ":7000"}}`))
+	assert.Error(t, err)
+
+	_, err = GetConfig(writeFile(t, dir, "config.ini", `address=:7000`))
+	assert.Error(t, err)
+}
+
+func TestGetConfig_Include(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, dir, "base.yaml", `
+server:
+  address: ":7000"
+  basepath: "/api"
+db:
+  postgresql: "user=leo"
+  max_open_conn: 20
+log:
+  level: debug
+  levels: {repository: debug}
+`)
+	writeFile(t, dir, "secrets.json", `{"db": {"postgresql": "user=leo password=secret"}}`)
+
+	cfg, err := GetConfig(writeFile(t, dir, "production.toml", `
+include = ["base.yaml", "secrets.json"]
+
+[server]
+address = ":80"
+
+[log]
+level = "warning"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ":80", cfg.Server.Address)
+	assert.Equal(t, "/api", cfg.Server.APIBasePath)
+	assert.Equal(t, "user=leo password=secret", cfg.DB.Connection)
+	assert.Equal(t, 20, cfg.DB.MaxOpenConn)
+	assert.Equal(t, "warning", cfg.Log.Level)
+	assert.Equal(t, map[string]string{"repository": "debug"}, cfg.Log.Levels)
+
+	writeFile(t, dir, "a.yaml", "include: b.yaml\n")
+	_, err = GetConfig(writeFile(t, dir, "b.yaml", "include: a.yaml\n"))
+	assert.True(t, errors.Is(err, ErrIncludeCycle))
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileSuffix - suffix of environment variable with path to file holding the value, e.g. mounted secret
+const fileSuffix = "_FILE"
+
+var (
+	// ErrUnknownField - override refers to field which does not exist
+	ErrUnknownField = errors.New("unknown config field")
+	// ErrUnsupportedField - field can not be set from string, e.g. list of structs
+	ErrUnsupportedField = errors.New("field can not be overridden")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Overrides - values set by flags as "path.to.field=value", path consists of yaml names.
+// It implements flag.Value to be used with repeated flag.
+type Overrides []string
+
+func (o *Overrides) String() string {
+	return strings.Join(*o, ", ")
+}
+
+func (o *Overrides) Set(s string) error {
+	if !strings.Contains(s, "=") {
+		return fmt.Errorf("expected path.to.field=value, got %q", s)
+	}
+	*o = append(*o, s)
+	return nil
+}
+
+// yamlName returns field name used in config file
+func yamlName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// leaves calls fn with yaml path of every field which can be overridden
+func leaves(t reflect.Type, path []string, fn func(path []string)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == durationType {
+		if settable(t) {
+			fn(path)
+		}
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || yamlName(f) == "-" {
+			continue
+		}
+		leaves(f.Type, append(append([]string{}, path...), yamlName(f)), fn)
+	}
+}
+
+func settable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// setPath sets field found by yaml path, nil parent structs are allocated
+func setPath(v reflect.Value, path []string, raw string) error {
+	for _, name := range path {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return ErrUnknownField
+		}
+
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath == "" && yamlName(f) == name {
+				v, found = v.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return ErrUnknownField
+		}
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	return setValue(v, raw)
+}
+
+// setValue parses raw according field type, lists are comma separated
+// and maps are comma separated key=value pairs
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return ErrUnsupportedField
+		}
+		items := splitList(raw)
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			s.Index(i).SetString(item)
+		}
+		v.Set(s)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return ErrUnsupportedField
+		}
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitList(raw) {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("expected key=value, got %q", item)
+			}
+			m.SetMapIndex(reflect.ValueOf(kv[0]).Convert(v.Type().Key()),
+				reflect.ValueOf(kv[1]).Convert(v.Type().Elem()))
+		}
+		v.Set(m)
+	default:
+		return ErrUnsupportedField
+	}
+
+	return nil
+}
+
+func splitList(raw string) []string {
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envName returns environment variable name of yaml path: SERVICE_DB_POSTGRESQL for db.postgresql
+func envName(prefix string, path []string) string {
+	name := strings.ToUpper(strings.Join(path, "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+// applyEnv sets fields from environment variables and from files named by _FILE variables
+func applyEnv(cfg *Config, prefix string, lookup func(string) (string, bool)) error {
+	var errs []string
+
+	leaves(reflect.TypeOf(cfg), nil, func(path []string) {
+		name := envName(prefix, path)
+
+		value, ok := lookup(name)
+		file, fok := lookup(name + fileSuffix)
+
+		switch {
+		case ok && fok:
+			errs = append(errs, fmt.Sprintf("both %s and %s are set", name, name+fileSuffix))
+			return
+		case fok:
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name+fileSuffix, err))
+				return
+			}
+			// editors and secret stores usually add trailing newline
+			value = strings.TrimRight(string(b), "\r\n")
+		case !ok:
+			return
+		}
+
+		if err := setPath(reflect.ValueOf(cfg), path, value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("environment: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// applyOverrides sets fields from flags
+func applyOverrides(cfg *Config, overrides Overrides) error {
+	for _, o := range overrides {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("override %q: expected path.to.field=value", o)
+		}
+		if err := setPath(reflect.ValueOf(cfg), strings.Split(kv[0], "."), kv[1]); err != nil {
+			return fmt.Errorf("override %s: %w", kv[0], err)
+		}
+	}
+	return nil
+}
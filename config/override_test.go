@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	p := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestLoader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeFile(t, dir, "config.yaml", `
+server:
+  address: ":7000"
+db:
+  postgresql: "user=leo"
+  max_open_conn: 20
+log:
+  level: debug
+`)
+	secret := writeFile(t, dir, "dsn", "user=leo password=secret\n")
+
+	env := map[string]string{
+		"SERVICE_SERVER_ADDRESS":       ":8000",
+		"SERVICE_DB_POSTGRESQL_FILE":   secret,
+		"SERVICE_DB_MAX_CONN_LIFETIME": "1h",
+		"SERVICE_LOG_LEVELS":           "repository=debug, usecase=info",
+		"SERVICE_CORS_ALLOW_ORIGINS":   "https://a.com,https://b.com",
+		"SERVICE_AUTH_ADMIN_KEY":       "key",
+		"OTHER_SERVER_ADDRESS":         ":9000",
+	}
+	lookup := mapLookup(env)
+
+	cfg, err := Loader{
+		Path:      path,
+		EnvPrefix: "SERVICE",
+		Overrides: Overrides{"server.address=:9090", "db.max_open_conn=5"},
+	}.load(lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ":9090", cfg.Server.Address)
+	assert.Equal(t, "user=leo password=secret", cfg.DB.Connection)
+	assert.Equal(t, 5, cfg.DB.MaxOpenConn)
+	assert.Equal(t, time.Hour, cfg.DB.MaxConnLifetime)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, map[string]string{"repository": "debug", "usecase": "info"}, cfg.Log.Levels)
+	assert.Equal(t, []string{"https://a.com", "https://b.com"}, cfg.CORS.AllowOrigins)
+	assert.Equal(t, "key", cfg.Auth.AdminKey)
+
+	env["SERVICE_DB_POSTGRESQL"] = "user=other"
+	_, err = Loader{Path: path, EnvPrefix: "SERVICE"}.load(lookup)
+	assert.Error(t, err, "both value and file are set")
+	delete(env, "SERVICE_DB_POSTGRESQL")
+
+	env["SERVICE_DB_MAX_OPEN_CONN"] = "many"
+	_, err = Loader{Path: path, EnvPrefix: "SERVICE"}.load(lookup)
+	assert.Error(t, err)
+	delete(env, "SERVICE_DB_MAX_OPEN_CONN")
+
+	_, err = Loader{Path: path, Overrides: Overrides{"db.unknown=1"}}.load(lookup)
+	assert.True(t, errors.Is(err, ErrUnknownField))
+}
+
+func TestOverrides_Set(t *testing.T) {
+	o := Overrides{}
+	assert.NoError(t, o.Set("server.address=:8000"))
+	assert.Error(t, o.Set("server.address"))
+	assert.Equal(t, Overrides{"server.address=:8000"}, o)
+}
+
+func TestLoader_Files(t *testing.T) {
+	files, err := filepath.Glob("*.yaml")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("find shipped configs: %v", err)
+	}
+
+	prefixes := map[string]string{"config.yaml": "SERVICE", "auth.yaml": "AUTH"}
+
+	for _, path := range files {
+		prefix := prefixes[path]
+		if prefix == "" {
+			prefix = "SERVICE"
+		}
+		loader := Loader{Path: path, EnvPrefix: prefix}
+
+		// shipped configs are used by commands and local runs without secrets
+		if _, err := loader.load(mapLookup(nil)); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+
+		env := map[string]string{prefix + "_TOKEN_SECRET": strings.Repeat("s", 32)}
+		if _, err := loader.load(mapLookup(env)); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+
+		env = map[string]string{prefix + "_TOKEN_SECRET": "change-me"}
+		if _, err := loader.load(mapLookup(env)); prefix == "AUTH" && (err == nil || !strings.Contains(err.Error(), "token.secret")) {
+			t.Errorf("%s: expected weak token secret to be rejected, got: %v", path, err)
+		}
+	}
+}
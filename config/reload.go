@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultReloadInterval - how often config file is checked for changes
+const DefaultReloadInterval = 5 * time.Second
+
+// reloadable - fields applied to running service, other changes need restart
+var reloadable = []string{
+	"log.level",
+	"log.levels",
+	"cors",
+	"access_log",
+	"db.max_open_conn",
+	"db.max_idle_conn",
+	"db.max_conn_lifetime",
+	"tracing.sample_rate",
+}
+
+// secretFields - values never shown in diff
+var secretFields = map[string]struct{}{
+	"postgresql": {},
+	"replicas":   {},
+	"secret":     {},
+	"admin_key":  {},
+}
+
+// ReloadConfig - SIGHUP reloads config in any case, Watch also polls the file
+type ReloadConfig struct {
+	Watch    bool          `yaml:"watch" json:"watch" toml:"watch"`
+	Interval time.Duration `yaml:"interval" json:"interval" toml:"interval"`
+}
+
+// Change - changed field, path consists of yaml names
+type Change struct {
+	Path     string
+	Old, New string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Reloadable reports whether change is applied without restart
+func (c Change) Reloadable() bool {
+	for _, p := range reloadable {
+		if c.Path == p || strings.HasPrefix(c.Path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed reports whether changes contain field path or fields of section path
+func Changed(changes []Change, path string) bool {
+	for _, c := range changes {
+		if c.Path == path || strings.HasPrefix(c.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten collects leaf values by yaml path, nil sections are absent
+func flatten(v reflect.Value, path string, out map[string]string) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" || yamlName(f) == "-" {
+				continue
+			}
+			flatten(v.Field(i), join(yamlName(f)), out)
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct:
+		for i := 0; i < v.Len(); i++ {
+			flatten(v.Index(i), join(fmt.Sprint(i)), out)
+		}
+	default:
+		out[path] = fmt.Sprint(v.Interface())
+	}
+}
+
+// Diff returns changed fields sorted by path, secret values are hidden
+func Diff(old, new Config) []Change {
+	before, after := map[string]string{}, map[string]string{}
+	flatten(reflect.ValueOf(old), "", before)
+	flatten(reflect.ValueOf(new), "", after)
+
+	changes := []Change{}
+	for path, o := range before {
+		if n, ok := after[path]; !ok || n != o {
+			changes = append(changes, Change{Path: path, Old: o, New: after[path]})
+		}
+	}
+	for path, n := range after {
+		if _, ok := before[path]; !ok {
+			changes = append(changes, Change{Path: path, New: n})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	for i, c := range changes {
+		if _, ok := secretFields[c.Path[strings.LastIndex(c.Path, ".")+1:]]; ok {
+			changes[i].Old, changes[i].New = "***", "***"
+		}
+	}
+
+	return changes
+}
+
+// Reloader reloads configuration and applies its reloadable part
+type Reloader struct {
+	loader  Loader
+	current Config
+	apply   func(cfg Config, changes []Change) error
+	log     *logrus.Entry
+
+	mu      sync.Mutex
+	content []byte
+}
+
+// NewReloader - apply is called with running config updated by reloadable fields of new
+// valid config and their changes if there are any
+func NewReloader(loader Loader, current Config, apply func(cfg Config, changes []Change) error, log *logrus.Entry) *Reloader {
+	content, _ := render(loader.Path)
+	return &Reloader{
+		loader:  loader,
+		current: current,
+		apply:   apply,
+		log:     log.WithField("config", loader.Path),
+		content: content,
+	}
+}
+
+// Reload loads config, invalid config is rejected and current one is kept
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.loader.Load()
+	if err != nil {
+		r.log.WithError(err).Error("reload config: rejected")
+		return err
+	}
+
+	var (
+		changes          []Change
+		applied, ignored []string
+	)
+	for _, c := range Diff(r.current, cfg) {
+		if c.Reloadable() {
+			changes = append(changes, c)
+			applied = append(applied, c.String())
+		} else {
+			ignored = append(ignored, c.String())
+		}
+	}
+
+	if len(ignored) > 0 {
+		r.log.WithField("changes", ignored).Warn("reload config: changes require restart")
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// running config takes only reloadable fields, so that changes requiring restart
+	// are reported until restart, and it is advanced only if they are applied
+	next := withReloadable(r.current, cfg)
+	if err = r.apply(next, changes); err != nil {
+		r.log.WithError(err).Error("reload config: apply")
+		return err
+	}
+	r.log.WithField("changes", applied).Info("reload config: applied")
+
+	r.current = next
+
+	return nil
+}
+
+// withReloadable returns copy of running config with reloadable fields of loaded one
+func withReloadable(running, loaded Config) Config {
+	next := running
+	for _, p := range reloadable {
+		copyPath(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded), strings.Split(p, "."))
+	}
+	return next
+}
+
+// copyPath sets field by yaml path from src to dst. Sections on the path are copied,
+// so that sections shared with running config are not changed.
+func copyPath(dst, src reflect.Value, path []string) {
+	if len(path) == 0 {
+		dst.Set(src)
+		return
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() && src.IsNil() {
+			return
+		}
+
+		section := reflect.New(dst.Type().Elem())
+		if !dst.IsNil() {
+			section.Elem().Set(dst.Elem())
+		}
+		dst.Set(section)
+
+		if src.IsNil() {
+			// removed section resets the field
+			src = reflect.New(src.Type().Elem())
+		}
+		copyPath(section.Elem(), src.Elem(), path)
+		return
+	}
+
+	for i := 0; i < dst.NumField(); i++ {
+		if f := dst.Type().Field(i); f.PkgPath == "" && yamlName(f) == path[0] {
+			copyPath(dst.Field(i), src.Field(i), path[1:])
+			return
+		}
+	}
+}
+
+// Watch polls config file and reloads it when content changes, until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// content is compared as editors and kubernetes config maps replace file,
+		// rendered content includes changes of included files
+		content, err := render(r.loader.Path)
+		if err != nil {
+			r.log.WithError(err).Warn("watch config")
+			continue
+		}
+
+		r.mu.Lock()
+		changed := !bytes.Equal(content, r.content)
+		r.content = content
+		r.mu.Unlock()
+
+		if changed {
+			_ = r.Reload()
+		}
+	}
+}
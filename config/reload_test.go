@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const reloadConfig = `
+server:
+  address: ":7000"
+db:
+  postgresql: "user=leo password=secret"
+  max_open_conn: 20
+log:
+  level: info
+`
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old, err := Loader{Path: writeFile(t, dir, "old.yaml", reloadConfig)}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	new, err := Loader{Path: writeFile(t, dir, "new.yaml", reloadConfig), Overrides: Overrides{
+		"log.level=warning",
+		"db.postgresql=user=leo password=other",
+		"server.address=:8000",
+		"cors.allow_origins=https://a.com",
+	}}.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := Diff(old, new)
+
+	assert.Equal(t, []Change{
+		{Path: "cors.allow_credentials", New: "false"},
+		{Path: "cors.allow_headers", New: "[]"},
+		{Path: "cors.allow_methods", New: "[]"},
+		{Path: "cors.allow_origins", New: "[https://a.com]"},
+		{Path: "cors.expose_headers", New: "[]"},
+		{Path: "cors.max_age", New: "0s"},
+		{Path: "db.postgresql", Old: "***", New: "***"},
+		{Path: "log.level", Old: "info", New: "warning"},
+		{Path: "server.address", Old: ":7000", New: ":8000"},
+	}, changes)
+
+	reloadable := map[string]bool{}
+	for _, c := range changes {
+		reloadable[c.Path] = c.Reloadable()
+	}
+	assert.True(t, reloadable["log.level"])
+	assert.True(t, reloadable["cors.allow_origins"])
+	assert.False(t, reloadable["server.address"])
+	assert.False(t, reloadable["db.postgresql"])
+}
+
+func TestReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	loader := Loader{Path: writeFile(t, dir, "config.yaml", reloadConfig)}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied := []Config{}
+	apply := func(cfg Config, changes []Change) error {
+		assert.True(t, Changed(changes, "log"))
+		assert.False(t, Changed(changes, "cors"))
+		applied = append(applied, cfg)
+		return nil
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	r := NewReloader(loader, cfg, apply, logrus.NewEntry(log))
+
+	// only restart requiring change
+	writeFile(t, dir, "config.yaml", reloadConfig+"  formatter: json\n")
+	assert.NoError(t, r.Reload())
+	assert.Len(t, applied, 0)
+
+	writeFile(t, dir, "config.yaml", reloadConfig+"  formatter: json\n  levels: {repository: debug}\n")
+	assert.NoError(t, r.Reload())
+	if assert.Len(t, applied, 1) {
+		assert.Equal(t, map[string]string{"repository": "debug"}, applied[0].Log.Levels)
+		// restart requiring change is not taken by running config
+		assert.NotEqual(t, "json", applied[0].Log.Formatter)
+		assert.Equal(t, ":7000", applied[0].Server.Address)
+	}
+	assert.NotEqual(t, "json", cfg.Log.Formatter, "running config must not be changed")
+
+	// invalid config is rejected
+	writeFile(t, dir, "config.yaml", reloadConfig+"  formatter: xml\n")
+	err = r.Reload()
+	verr := &ValidationError{}
+	assert.True(t, errors.As(err, &verr))
+	assert.Len(t, applied, 1)
+}
+
+func TestReloader_ApplyFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	loader := Loader{Path: writeFile(t, dir, "config.yaml", reloadConfig)}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	apply := func(cfg Config, changes []Change) error {
+		calls++
+		if calls == 1 {
+			return errors.New("access log: bad trusted proxy")
+		}
+		return nil
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	r := NewReloader(loader, cfg, apply, logrus.NewEntry(log))
+
+	writeFile(t, dir, "config.yaml", reloadConfig+"  levels: {repository: debug}\n")
+	assert.Error(t, r.Reload())
+	assert.Equal(t, cfg, r.current)
+
+	// changes not applied are applied by the next reload
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, map[string]string{"repository": "debug"}, r.current.Log.Levels)
+
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/moguchev/service/pkg/health"
+	logger "github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/pgsql"
+)
+
+const (
+	DefaultAddress         = ":7000"
+	DefaultMaxOpenConn     = 20
+	DefaultMaxIdleConn     = 10
+	DefaultMaxConnLifetime = time.Hour
+)
+
+// ValidationError - all problems found in configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *ValidationError) add(field string, err error) {
+	if err != nil {
+		e.Problems = append(e.Problems, field+": "+err.Error())
+	}
+}
+
+// SetDefaults fills required sections and zero fields. Optional sections
+// (cors, access_log, token, password, tracing) stay nil as nil means disabled or built-in policy.
+func (cfg *Config) SetDefaults() {
+	if cfg.Server == nil {
+		cfg.Server = &ServerConfig{}
+	}
+	if cfg.Server.Address == "" {
+		cfg.Server.Address = DefaultAddress
+	}
+	if cfg.Server.HealthTimeout == 0 {
+		cfg.Server.HealthTimeout = health.DefaultTimeout
+	}
+
+	if cfg.DB == nil {
+		cfg.DB = &pgsql.Config{}
+	}
+	if cfg.DB.MaxOpenConn == 0 {
+		cfg.DB.MaxOpenConn = DefaultMaxOpenConn
+	}
+	if cfg.DB.MaxIdleConn == 0 {
+		cfg.DB.MaxIdleConn = DefaultMaxIdleConn
+		if cfg.DB.MaxIdleConn > cfg.DB.MaxOpenConn {
+			cfg.DB.MaxIdleConn = cfg.DB.MaxOpenConn
+		}
+	}
+	if cfg.DB.MaxConnLifetime == 0 {
+		cfg.DB.MaxConnLifetime = DefaultMaxConnLifetime
+	}
+	if cfg.DB.StartupTimeout == 0 {
+		cfg.DB.StartupTimeout = pgsql.DefaultStartupTimeout
+	}
+	if cfg.DB.StartupMaxDelay == 0 {
+		cfg.DB.StartupMaxDelay = pgsql.DefaultStartupMaxDelay
+	}
+	if cfg.DB.ReplicaCheckInterval == 0 {
+		cfg.DB.ReplicaCheckInterval = pgsql.DefaultReplicaCheckInterval
+	}
+	if cfg.DB.Backend == "" {
+		cfg.DB.Backend = pgsql.BackendSQLX
+	}
+	if cfg.DB.Migrations == "" {
+		cfg.DB.Migrations = pgsql.MigrationsAuto
+	}
+	if cfg.DB.MigrationLockTimeout == 0 {
+		cfg.DB.MigrationLockTimeout = pgsql.DefaultMigrationLockTimeout
+	}
+
+	if cfg.Log == nil {
+		cfg.Log = &logger.Config{}
+	}
+	if cfg.Log.Output == "" && len(cfg.Log.Outputs) == 0 {
+		cfg.Log.Output = logger.Stdout
+	}
+	if cfg.Log.Formatter == "" && len(cfg.Log.Outputs) == 0 {
+		cfg.Log.Formatter = logger.TextFormatter
+	}
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = "debug"
+	}
+
+	if cfg.Auth == nil {
+		cfg.Auth = &AuthConfig{}
+	}
+}
+
+func validateAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+func notNegative(values map[string]int64) error {
+	for name, v := range values {
+		if v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// Validate checks all sections and returns *ValidationError listing every problem
+func (cfg Config) Validate() error {
+	errs := &ValidationError{}
+
+	if s := cfg.Server; s != nil {
+		errs.add("server.address", validateAddress(s.Address))
+		if s.AdminAddress != "" {
+			errs.add("server.admin_address", validateAddress(s.AdminAddress))
+		}
+		if s.APIBasePath != "" && !strings.HasPrefix(s.APIBasePath, "/") {
+			errs.add("server.basepath", fmt.Errorf("must start with /, got %q", s.APIBasePath))
+		}
+		if s.HealthTimeout <= 0 {
+			errs.add("server.health_timeout", fmt.Errorf("must be positive, got %v", s.HealthTimeout))
+		}
+	}
+
+	if db := cfg.DB; db != nil {
+		if db.Connection == "" {
+			errs.add("db.postgresql", fmt.Errorf("is required"))
+		} else if _, err := pgx.ParseConfig(db.Connection); err != nil {
+			// parse error may contain the connection string itself
+			errs.add("db.postgresql", fmt.Errorf("invalid connection string"))
+		}
+		for i, dsn := range db.Replicas {
+			if _, err := pgx.ParseConfig(dsn); err != nil {
+				errs.add(fmt.Sprintf("db.replicas.%d", i), fmt.Errorf("invalid connection string"))
+			}
+		}
+		errs.add("db", notNegative(map[string]int64{
+			"max_open_conn":          int64(db.MaxOpenConn),
+			"max_idle_conn":          int64(db.MaxIdleConn),
+			"max_conn_lifetime":      int64(db.MaxConnLifetime),
+			"migration_lock_timeout": int64(db.MigrationLockTimeout),
+			"min_conns":              int64(db.MinConns),
+			"health_check_period":    int64(db.HealthCheckPeriod),
+			"max_replication_lag":    int64(db.MaxReplicationLag),
+			"startup_timeout":        int64(db.StartupTimeout),
+			"startup_max_delay":      int64(db.StartupMaxDelay),
+			"replica_check_interval": int64(db.ReplicaCheckInterval),
+		}))
+		if db.MaxOpenConn > 0 && int(db.MinConns) > db.MaxOpenConn {
+			errs.add("db.min_conns", fmt.Errorf("%d is greater than max_open_conn %d", db.MinConns, db.MaxOpenConn))
+		}
+		if r := db.Retry; r != nil {
+			errs.add("db.retry", notNegative(map[string]int64{
+				"base_delay": int64(r.BaseDelay),
+				"max_delay":  int64(r.MaxDelay),
+			}))
+		}
+		if b := db.Breaker; b != nil {
+			errs.add("db.breaker", notNegative(map[string]int64{
+				"threshold":    int64(b.Threshold),
+				"open_timeout": int64(b.OpenTimeout),
+			}))
+		}
+		if _, err := pgsql.ParseIsolation(db.TxIsolation); err != nil {
+			errs.add("db.tx_isolation", err)
+		}
+		switch db.Backend {
+		case "", pgsql.BackendSQLX:
+		case pgsql.BackendPgx:
+			// reads are not routed to replicas by pgx pool, they would be silently ignored
+			if len(db.Replicas) > 0 {
+				errs.add("db.backend", fmt.Errorf("%s does not support replicas, use %s", pgsql.BackendPgx, pgsql.BackendSQLX))
+			}
+		default:
+			errs.add("db.backend", fmt.Errorf("must be one of %s|%s, got %q", pgsql.BackendSQLX, pgsql.BackendPgx, db.Backend))
+		}
+		switch db.Migrations {
+		case "", pgsql.MigrationsAuto, pgsql.MigrationsCheck:
+		default:
+			errs.add("db.migrations", fmt.Errorf("must be one of %s|%s, got %q", pgsql.MigrationsAuto, pgsql.MigrationsCheck, db.Migrations))
+		}
+		for _, dir := range db.ExtraMigrations {
+			if info, err := os.Stat(dir); err != nil {
+				errs.add("db.extra_migrations", err)
+			} else if !info.IsDir() {
+				errs.add("db.extra_migrations", fmt.Errorf("%s is not a directory", dir))
+			}
+		}
+		if db.MaxOpenConn > 0 && db.MaxIdleConn > db.MaxOpenConn {
+			errs.add("db.max_idle_conn", fmt.Errorf("%d is greater than max_open_conn %d", db.MaxIdleConn, db.MaxOpenConn))
+		}
+	}
+
+	if cfg.Log != nil {
+		errs.add("log", cfg.Log.Validate())
+	}
+
+	if c := cfg.CORS; c != nil {
+		if c.MaxAge < 0 {
+			errs.add("cors.max_age", fmt.Errorf("must not be negative"))
+		}
+		for _, o := range c.AllowOrigins {
+			if o == "*" && c.AllowCredentials {
+				errs.add("cors.allow_origins", fmt.Errorf("\"*\" with allow_credentials reflects any origin"))
+			}
+		}
+	}
+
+	if cfg.AccessLog != nil {
+		errs.add("access_log", cfg.AccessLog.Validate())
+	}
+
+	if t := cfg.Token; t != nil {
+		// secret may be not set while tokens are not used, e.g. by commands,
+		// binaries issuing or verifying tokens require it on start
+		if t.Secret != "" {
+			errs.add("token.secret", t.Validate())
+		}
+		errs.add("token", notNegative(map[string]int64{
+			"access_ttl":  int64(t.AccessTTL),
+			"refresh_ttl": int64(t.RefreshTTL),
+		}))
+	}
+
+	if cfg.Password != nil {
+		_, err := cfg.Password.CreateHasher()
+		errs.add("password", err)
+	}
+
+	if cfg.Tracing != nil {
+		errs.add("tracing", cfg.Tracing.Validate())
+	}
+
+	if cfg.Reload != nil && cfg.Reload.Interval < 0 {
+		errs.add("reload.interval", fmt.Errorf("must not be negative"))
+	}
+
+	if len(errs.Problems) > 0 {
+		return errs
+	}
+
+	return nil
+}
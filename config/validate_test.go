@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeFile(t, dir, "config.yaml", `
+server:
+  address: "localhost"
+  basepath: "api"
+db:
+  max_open_conn: 5
+  max_idle_conn: 10
+  migrations: manual
+  backend: pgx
+  replicas: ["host=replica"]
+log:
+  level: verbose
+tracing:
+  exporter: zipkin
+`)
+
+	_, err = Loader{Path: path}.Load()
+
+	verr := &ValidationError{}
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got: %v", err)
+	}
+
+	assert.Len(t, verr.Problems, 8)
+	assert.Contains(t, err.Error(), "server.address")
+	assert.Contains(t, err.Error(), "server.basepath")
+	assert.Contains(t, err.Error(), "db.postgresql: is required")
+	assert.Contains(t, err.Error(), "db.max_idle_conn")
+	assert.Contains(t, err.Error(), `db.migrations: must be one of auto|check, got "manual"`)
+	assert.Contains(t, err.Error(), "db.backend: pgx does not support replicas")
+	assert.Contains(t, err.Error(), `log: unknown level "verbose"`)
+	assert.Contains(t, err.Error(), "tracing: endpoint is required")
+}
+
+func TestGetConfig_Strict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = GetConfig(writeFile(t, dir, "config.yaml", "server:\n  adress: \":7000\"\n"))
+	assert.Error(t, err)
+
+	cfg, err := GetConfig(writeFile(t, dir, "empty.yaml", ""))
+	assert.NoError(t, err)
+
+	cfg.SetDefaults()
+	assert.Equal(t, DefaultAddress, cfg.Server.Address)
+	assert.Equal(t, DefaultMaxOpenConn, cfg.DB.MaxOpenConn)
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
@@ -1,23 +1,34 @@
 module github.com/moguchev/service
 
-go 1.15
+go 1.16
 
 require (
+	contrib.go.opencensus.io/exporter/jaeger v0.2.1
+	contrib.go.opencensus.io/exporter/zipkin v0.1.2
+	github.com/BurntSushi/toml v0.3.1
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/Masterminds/squirrel v1.5.0
-	github.com/golang-migrate/migrate v3.5.4+incompatible
+	github.com/go-kit/kit v0.10.0 // indirect
+	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/golang-migrate/migrate/v4 v4.11.0
 	github.com/google/martian v2.1.0+incompatible
 	github.com/gorilla/mux v1.7.4
+	github.com/jackc/pgconn v1.8.0
 	github.com/jackc/pgx/v4 v4.10.0
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/opencensus-integrations/ocsql v0.1.7
-	github.com/shurcooL/httpfs v0.0.0-20190707220628-8d4bc4ba7749 // indirect
-	github.com/shurcooL/vfsgen v0.0.0-20200824052919-0d455de96546 // indirect
+	github.com/openzipkin/zipkin-go v0.2.2
+	github.com/prometheus/client_golang v1.11.1
 	github.com/sirupsen/logrus v1.7.0
-	github.com/stretchr/testify v1.5.1
+	github.com/stretchr/testify v1.7.1
 	gitlab.services.mts.ru/abp/myosotis v0.4.0
-	go.opencensus.io v0.22.5 // indirect
-	golang.org/x/sync v0.0.0-20200625203802-6e8e738ad208
+	go.opencensus.io v0.22.6-0.20201102222123-380f4078db9f
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/bridge/opencensus v0.30.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	golang.org/x/sync v0.0.0-20201207232520-09787c993a3a
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0
 	gopkg.in/yaml.v2 v2.4.0
 )
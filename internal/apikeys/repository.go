@@ -0,0 +1,17 @@
+package apikeys
+
+import (
+	"context"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+)
+
+// Repository - database level
+type Repository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context) (models.APIKeys, error)
+	RevokeAPIKey(ctx context.Context, id int64, at time.Time) error
+	TouchAPIKey(ctx context.Context, id int64, at time.Time) error
+}
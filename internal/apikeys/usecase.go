@@ -0,0 +1,16 @@
+package apikeys
+
+import (
+	"context"
+
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/auth"
+)
+
+// Usecase - business logic
+type Usecase interface {
+	IssueAPIKey(ctx context.Context, req models.NewAPIKey) (models.APIKey, string, error)
+	GetAPIKeys(ctx context.Context) (models.APIKeys, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	AuthenticateAPIKey(ctx context.Context, key string) (auth.Principal, error)
+}
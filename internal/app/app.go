@@ -0,0 +1,254 @@
+// Package app contains bootstrap shared by service binaries: logger, tracing,
+// middlewares, config reload, signal handling and graceful shutdown of servers.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/config"
+	"github.com/moguchev/service/pkg/health"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/middleware"
+	"github.com/moguchev/service/pkg/tracing"
+	"github.com/moguchev/service/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// shutdownTimeout - time given to servers to finish active requests
+const shutdownTimeout = 30 * time.Second
+
+// App - running binary with its config, logger and common middlewares
+type App struct {
+	Config config.Config
+	Log    *logrus.Logger
+	MW     *middleware.Middleware
+
+	loader   config.Loader
+	tracer   tracing.Flusher
+	ctx      context.Context
+	cancel   context.CancelFunc
+	bctx     context.Context
+	appliers []func(cfg config.Config)
+}
+
+// New creates logger and inits tracing from loaded config,
+// name is used as tracing service name if config has none
+func New(loader config.Loader, cfg config.Config, name string) (*App, error) {
+	log, err := cfg.Log.NewLogger()
+	if err != nil {
+		return nil, fmt.Errorf("create logger: %w", err)
+	}
+
+	// cfg is not modified to be compared on reload
+	tcfg := tracing.Config{}
+	if cfg.Tracing != nil {
+		tcfg = *cfg.Tracing
+	}
+	if tcfg.ServiceName == "" {
+		tcfg.ServiceName = name
+	}
+
+	tracer, err := tcfg.Init(log)
+	if err != nil {
+		return nil, fmt.Errorf("init tracing: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &App{
+		Config: cfg,
+		Log:    log,
+		MW:     middleware.InitMiddleware(log),
+		loader: loader,
+		tracer: tracer,
+		ctx:    logger.WithLogger(ctx, log),
+		cancel: cancel,
+		bctx:   logger.WithLogger(context.Background(), log),
+	}, nil
+}
+
+// Context - context canceled on shutdown, carries logger
+func (a *App) Context() context.Context {
+	return a.ctx
+}
+
+// OnReload adds function setting reloadable config to component of binary,
+// e.g. db pool limits
+func (a *App) OnReload(apply func(cfg config.Config)) {
+	a.appliers = append(a.appliers, apply)
+}
+
+// Handler wraps router itself with common middlewares after given ones,
+// so unmatched requests (404, 405, CORS preflight) are handled too
+func (a *App) Handler(router *mux.Router, mws ...mux.MiddlewareFunc) http.Handler {
+	mws = append(mws,
+		tracing.Middleware,
+		a.MW.RequestIDMiddleware,
+		a.MW.AccessLogMiddleware,
+		a.MW.RecoverMiddleware,
+		a.MW.CORSMiddleware,
+	)
+	return utils.WrapRouter(router, mws...)
+}
+
+// Server returns server with base context carrying logger
+func (a *App) Server(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler: handler,
+		Addr:    addr,
+		BaseContext: func(net.Listener) context.Context {
+			return a.bctx
+		},
+	}
+}
+
+// applyConfig sets reloadable part of config to running components. Everything which
+// may fail is prepared first, so that rejected config changes nothing.
+func (a *App) applyConfig(cfg config.Config, changes []config.Change) error {
+	// levels set by /log-level are kept until log section is changed,
+	// logger is created with levels from config already
+	var levels *logger.Levels
+	if config.Changed(changes, "log") {
+		l, err := logger.ParseLevels(cfg.Log.Level, cfg.Log.Levels)
+		if err != nil {
+			return fmt.Errorf("log: %w", err)
+		}
+		levels = &l
+	}
+
+	access := middleware.AccessLogConfig{}
+	if cfg.AccessLog != nil {
+		access = *cfg.AccessLog
+	}
+	setAccessLog, err := a.MW.PrepareAccessLog(access)
+	if err != nil {
+		return fmt.Errorf("access log: %w", err)
+	}
+
+	if levels != nil {
+		logger.SetLevels(a.Log, *levels)
+	}
+
+	cors := middleware.DefaultCorsData
+	if cfg.CORS != nil {
+		cors = *cfg.CORS
+	}
+	a.MW.SetCORS(cors)
+
+	setAccessLog()
+
+	for _, apply := range a.appliers {
+		apply(cfg)
+	}
+
+	if cfg.Tracing != nil {
+		cfg.Tracing.SetSampler()
+	}
+
+	return nil
+}
+
+// Run serves servers until stop signal or failure of any of them, then drains
+// them in given order. SIGHUP reloads config, SIGUSR1 reopens log files.
+// Tracing and log files are closed before return.
+func (a *App) Run(checker *health.Checker, servers ...*http.Server) error {
+	err := a.run(checker, servers)
+
+	// spans of the last requests are sent after servers are stopped
+	if terr := a.tracer.Close(); terr != nil {
+		a.Log.WithError(terr).Error("close tracing")
+	}
+
+	if err != nil {
+		a.Log.WithError(err).Error("stopped")
+	}
+
+	// log files are closed last
+	_ = logger.Close()
+
+	return err
+}
+
+func (a *App) run(checker *health.Checker, servers []*http.Server) error {
+	defer a.cancel()
+
+	if err := a.applyConfig(a.Config, nil); err != nil {
+		return fmt.Errorf("apply config: %w", err)
+	}
+
+	reloader := config.NewReloader(a.loader, a.Config, a.applyConfig, logrus.NewEntry(a.Log))
+	if a.Config.Reload != nil && a.Config.Reload.Watch {
+		go reloader.Watch(a.ctx, a.Config.Reload.Interval)
+	}
+
+	group, gctx := errgroup.WithContext(a.ctx)
+	for _, srv := range servers {
+		srv := srv
+		group.Go(func() error {
+			err := srv.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("server %s: %w", srv.Addr, err)
+			}
+			return nil
+		})
+	}
+
+	group.Go(func() error {
+		sgnl := make(chan os.Signal, 1)
+		signal.Notify(sgnl,
+			syscall.SIGHUP,
+			syscall.SIGUSR1,
+			syscall.SIGINT,
+			syscall.SIGTERM,
+			syscall.SIGQUIT,
+		)
+		defer signal.Stop(sgnl)
+	wait:
+		for {
+			select {
+			case stop := <-sgnl:
+				switch stop {
+				case syscall.SIGHUP:
+					_ = reloader.Reload()
+					continue
+				case syscall.SIGUSR1:
+					if err := logger.Reopen(); err != nil {
+						a.Log.WithError(err).Error("reopen log files")
+					}
+					continue
+				}
+				a.Log.WithField("signal", stop).Info("waiting for all processes to stop")
+			case <-gctx.Done():
+				a.Log.Info("server failed, stopping")
+			}
+			break wait
+		}
+
+		// readiness fails from now on, while active requests are drained
+		checker.Shutdown()
+		a.cancel()
+
+		sctx, scancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer scancel()
+
+		var err error
+		for _, srv := range servers {
+			if serr := srv.Shutdown(sctx); err == nil {
+				err = serr
+			}
+		}
+		return err
+	})
+
+	return group.Wait()
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/config"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyConfig_Rejected(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.InfoLevel)
+	a := &App{Log: log, MW: middleware.InitMiddleware(log)}
+
+	applied := false
+	a.OnReload(func(cfg config.Config) { applied = true })
+
+	cfg := config.Config{
+		Log:       &logger.Config{Level: "error"},
+		CORS:      &middleware.CorsData{AllowOrigins: []string{"https://a.com"}},
+		AccessLog: &middleware.AccessLogConfig{TrustedProxies: []string{"proxy"}},
+	}
+
+	err := a.applyConfig(cfg, []config.Change{{Path: "log.level"}, {Path: "cors.allow_origins"}})
+	assert.Error(t, err)
+
+	// nothing is applied partially
+	assert.False(t, applied)
+	assert.Equal(t, logrus.InfoLevel, log.GetLevel())
+
+	router := mux.NewRouter()
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://b.com")
+	res := httptest.NewRecorder()
+	a.MW.CORSMiddleware(router).ServeHTTP(res, req)
+	assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+
+	cfg.AccessLog = nil
+	assert.NoError(t, a.applyConfig(cfg, []config.Change{{Path: "log.level"}}))
+	assert.True(t, applied)
+	assert.Equal(t, logrus.ErrorLevel, log.GetLevel())
+}
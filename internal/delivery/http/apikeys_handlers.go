@@ -0,0 +1,118 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/internal/apikeys"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/utils"
+)
+
+const (
+	apiKeyIDParam = "id"
+)
+
+// APIKeysHandler represent the http handler for api keys administration
+type APIKeysHandler struct {
+	Usecase apikeys.Usecase
+}
+
+// SetAPIKeysHandler will initialize the api-keys/ resources endpoint
+func SetAPIKeysHandler(router *mux.Router, uc apikeys.Usecase) {
+	handler := &APIKeysHandler{
+		Usecase: uc,
+	}
+
+	router.HandleFunc("/api-keys", handler.GetAPIKeysHandler).Methods(http.MethodGet)
+	router.HandleFunc("/api-keys", handler.IssueAPIKeyHandler).Methods(http.MethodPost)
+	router.HandleFunc(fmt.Sprintf("/api-keys/{%s}", apiKeyIDParam),
+		handler.RevokeAPIKeyHandler).Methods(http.MethodDelete)
+}
+
+// GetAPIKeysHandler -
+func (h *APIKeysHandler) GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "GetAPIKeysHandler")
+
+	keys, err := h.Usecase.GetAPIKeys(ctx)
+	if err != nil {
+		log.WithError(err).Error("get api keys")
+		utils.RespondWithError(w, r, http.StatusInternalServerError, models.ErrInternal)
+		return
+	}
+
+	type Response struct {
+		Keys models.APIKeys `json:"keys"`
+	}
+
+	utils.RespondWithJSON(w, r, http.StatusOK, Response{Keys: keys})
+}
+
+// IssueAPIKeyHandler - responds with the key, it is shown only once
+func (h *APIKeysHandler) IssueAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "IssueAPIKeyHandler")
+
+	req := models.NewAPIKey{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("decode body")
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Name == "" {
+		utils.RespondWithError(w, r, http.StatusBadRequest, fmt.Errorf("name: required"))
+		return
+	}
+
+	key, plain, err := h.Usecase.IssueAPIKey(ctx, req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidArgument) {
+			utils.RespondWithError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		log.WithError(err).Error("issue api key")
+		utils.RespondWithError(w, r, http.StatusInternalServerError, models.ErrInternal)
+		return
+	}
+
+	type Response struct {
+		models.APIKey
+		Key string `json:"key"`
+	}
+
+	utils.RespondWithJSON(w, r, http.StatusCreated, Response{APIKey: key, Key: plain})
+}
+
+// RevokeAPIKeyHandler -
+func (h *APIKeysHandler) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "RevokeAPIKeyHandler")
+
+	id := mux.Vars(r)[apiKeyIDParam]
+
+	keyID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		log.WithError(err).WithField(apiKeyIDParam, id).Error("parse")
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = h.Usecase.RevokeAPIKey(ctx, keyID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			utils.RespondWithError(w, r, http.StatusNotFound, models.ErrNotFound)
+			return
+		}
+		log.WithError(err).Error("revoke api key")
+		utils.RespondWithError(w, r, http.StatusInternalServerError, models.ErrInternal)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
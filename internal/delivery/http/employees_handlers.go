@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -49,6 +50,11 @@ func (h *EmployeesHandler) GetEmployeesHandler(w http.ResponseWriter, r *http.Re
 	log.WithField("filter", filter).Debug("get employees")
 
 	total, emps, err := h.Usecase.GetEmployees(ctx, filter)
+	if errors.Is(err, models.ErrUnavailable) {
+		log.WithError(err).Warn("get employees")
+		utils.RespondWithError(w, r, http.StatusServiceUnavailable, models.ErrUnavailable)
+		return
+	}
 	if err != nil {
 		log.WithError(err).Error("get employees")
 		utils.RespondWithError(w, r, http.StatusInternalServerError, models.ErrInternal)
@@ -139,6 +145,11 @@ func (h *EmployeesHandler) GetEmployeeByIDHandler(w http.ResponseWriter, r *http
 	}
 
 	_, emps, err := h.Usecase.GetEmployees(ctx, models.EmployeeFilter{EmployeeID: &empID})
+	if errors.Is(err, models.ErrUnavailable) {
+		log.WithError(err).Warn("get employee by id")
+		utils.RespondWithError(w, r, http.StatusServiceUnavailable, models.ErrUnavailable)
+		return
+	}
 	if err != nil {
 		log.WithError(err).Error("get employee by id")
 		utils.RespondWithError(w, r, http.StatusInternalServerError, models.ErrInternal)
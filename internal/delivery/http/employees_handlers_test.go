@@ -236,6 +236,30 @@ func TestGetEmployeesHandler_InternalError(t *testing.T) {
 	}
 }
 
+type employeesUsecaseUnavailableMock struct{}
+
+func (mock *employeesUsecaseUnavailableMock) GetEmployees(ctx context.Context, f models.EmployeeFilter) (uint, models.Employees, error) {
+	return 0, nil, fmt.Errorf("get employees: %w", models.ErrUnavailable)
+}
+
+func TestGetEmployeesHandler_Unavailable(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/employees/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := EmployeesHandler{&employeesUsecaseUnavailableMock{}}
+	handler := http.HandlerFunc(h.GetEmployeesHandler)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusServiceUnavailable)
+	}
+}
+
 func TestGetEmployeeByIDHandler_BadRequest(t *testing.T) {
 	req, err := http.NewRequest(http.MethodGet, "/employees/abcd", nil)
 	if err != nil {
@@ -0,0 +1,136 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/internal/users"
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/utils"
+)
+
+// UsersHandler represent the http handler for registration and tokens
+type UsersHandler struct {
+	Usecase users.Usecase
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SetUsersHandler will initialize the authorization endpoints
+func SetUsersHandler(router *mux.Router, uc users.Usecase) {
+	handler := &UsersHandler{
+		Usecase: uc,
+	}
+
+	router.HandleFunc("/register", handler.RegisterHandler).Methods(http.MethodPost)
+	router.HandleFunc("/login", handler.LoginHandler).Methods(http.MethodPost)
+	router.HandleFunc("/refresh", handler.RefreshHandler).Methods(http.MethodPost)
+	router.HandleFunc("/revoke", handler.RevokeHandler).Methods(http.MethodPost)
+}
+
+// respondWithAuthError maps usecase errors to http codes
+func respondWithAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidArgument):
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+	case errors.Is(err, models.ErrAlreadyExists):
+		utils.RespondWithError(w, r, http.StatusConflict, models.ErrAlreadyExists)
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		utils.RespondWithError(w, r, http.StatusUnauthorized, err)
+	default:
+		utils.RespondWithError(w, r, http.StatusInternalServerError, models.ErrInternal)
+	}
+}
+
+// RegisterHandler -
+func (h *UsersHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "RegisterHandler")
+
+	c := models.Credentials{}
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		log.WithError(err).Error("decode body")
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := h.Usecase.Register(ctx, c)
+	if err != nil {
+		log.WithError(err).Error("register")
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, r, http.StatusCreated, user)
+}
+
+// LoginHandler -
+func (h *UsersHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "LoginHandler")
+
+	c := models.Credentials{}
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		log.WithError(err).Error("decode body")
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	pair, err := h.Usecase.Login(ctx, c)
+	if err != nil {
+		log.WithError(err).Error("login")
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, r, http.StatusOK, pair)
+}
+
+// RefreshHandler -
+func (h *UsersHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "RefreshHandler")
+
+	req := refreshTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("decode body")
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	pair, err := h.Usecase.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		log.WithError(err).Error("refresh")
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, r, http.StatusOK, pair)
+}
+
+// RevokeHandler -
+func (h *UsersHandler) RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.GetLogger(ctx).WithField("handler", "RevokeHandler")
+
+	req := refreshTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.WithError(err).Error("decode body")
+		utils.RespondWithError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.Usecase.Revoke(ctx, req.RefreshToken); err != nil {
+		log.WithError(err).Error("revoke")
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// ScopeAdmin - allows to manage api keys
+	ScopeAdmin = "admin"
+	// ScopeEmployeesRead - allows to read employees
+	ScopeEmployeesRead = "employees:read"
+)
+
+type (
+	// Scopes - list of permissions, stored as space separated string
+	Scopes []string
+
+	// APIKey - api key info (the key itself is never stored)
+	APIKey struct {
+		ID         int64      `json:"id" db:"id"`
+		Name       string     `json:"name" db:"name"`
+		Prefix     string     `json:"prefix" db:"prefix"`
+		Scopes     Scopes     `json:"scopes" db:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+		CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+		RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	}
+
+	// APIKeys - array of api keys info
+	APIKeys []APIKey
+
+	// NewAPIKey - request for api key issuing
+	NewAPIKey struct {
+		Name      string     `json:"name"`
+		Scopes    Scopes     `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	}
+)
+
+// Has reports whether scope is present
+func (s Scopes) Has(scope string) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+// Scan implements sql.Scanner
+func (s *Scopes) Scan(src interface{}) error {
+	var str string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return fmt.Errorf("scan scopes: unsupported type %T", src)
+	}
+	*s = strings.Fields(str)
+	return nil
+}
+
+// IsActive reports whether key is neither revoked nor expired at moment t
+func (k APIKey) IsActive(t time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || t.Before(*k.ExpiresAt)
+}
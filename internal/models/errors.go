@@ -2,9 +2,25 @@ package models
 
 import (
 	"fmt"
+
+	"github.com/moguchev/service/pkg/auth"
 )
 
 var (
 	// ErrInternal -
 	ErrInternal = fmt.Errorf("internal error")
+	// ErrNotFound -
+	ErrNotFound = fmt.Errorf("not found")
+	// ErrInvalidArgument -
+	ErrInvalidArgument = fmt.Errorf("invalid argument")
+	// ErrAlreadyExists -
+	ErrAlreadyExists = fmt.Errorf("already exists")
+	// ErrUnavailable - storage is temporarily unavailable
+	ErrUnavailable = fmt.Errorf("service unavailable")
+	// ErrInvalidCredentials -
+	ErrInvalidCredentials = fmt.Errorf("invalid login or password: %w", auth.ErrInvalidCredentials)
+	// ErrInvalidRefreshToken -
+	ErrInvalidRefreshToken = fmt.Errorf("invalid refresh token: %w", auth.ErrInvalidCredentials)
+	// ErrInvalidAPIKey -
+	ErrInvalidAPIKey = fmt.Errorf("invalid api key: %w", auth.ErrInvalidCredentials)
 )
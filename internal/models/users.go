@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type (
+	// User - registered user (password hash is never exposed)
+	User struct {
+		ID           int64     `json:"id" db:"id"`
+		Login        string    `json:"login" db:"login"`
+		PasswordHash string    `json:"-" db:"password_hash"`
+		Scopes       Scopes    `json:"scopes" db:"scopes"`
+		CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	}
+
+	// Credentials - login and password pair
+	Credentials struct {
+		Login    string `json:"login"`
+		Password string `json:"password"`
+	}
+
+	// RefreshToken - issued refresh token info (the token itself is never stored)
+	RefreshToken struct {
+		ID        int64      `db:"id"`
+		UserID    int64      `db:"user_id"`
+		ExpiresAt time.Time  `db:"expires_at"`
+		CreatedAt time.Time  `db:"created_at"`
+		RevokedAt *time.Time `db:"revoked_at"`
+	}
+
+	// TokenPair - tokens returned to user after login or refresh
+	TokenPair struct {
+		AccessToken  string    `json:"access_token"`
+		TokenType    string    `json:"token_type"`
+		ExpiresAt    time.Time `json:"expires_at"`
+		RefreshToken string    `json:"refresh_token"`
+	}
+)
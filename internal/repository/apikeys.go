@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/moguchev/service/internal/apikeys"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/pgsql"
+	"github.com/sirupsen/logrus"
+)
+
+var apiKeyColumns = []string{"id", "name", "prefix", "scopes", "expires_at",
+	"created_at", "revoked_at", "last_used_at"}
+
+type apiKeysRepository struct {
+	db *sqlx.DB
+}
+
+// NewAPIKeysRepository will create an object that represent the apikeys.Repository interface
+func NewAPIKeysRepository(db *sqlx.DB) apikeys.Repository {
+	return &apiKeysRepository{db: db}
+}
+
+func (r *apiKeysRepository) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "CreateAPIKey",
+	})
+
+	query := sq.Insert("api_keys").
+		Columns("name", "prefix", "key_hash", "scopes", "expires_at").
+		Values(key.Name, key.Prefix, hash, key.Scopes, key.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sql)
+
+	log.Debug("create api key")
+
+	if err = pgsql.Conn(ctx, r.db).QueryRowxContext(ctx, sql, args...).Scan(&key.ID, &key.CreatedAt); err != nil {
+		log.WithError(err).Error("create api key")
+		return models.APIKey{}, fmt.Errorf("create api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeysRepository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "GetAPIKeyByHash",
+	})
+
+	query := sq.Select(apiKeyColumns...).From("api_keys").
+		Where(sq.Eq{"key_hash": hash}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sqlStr)
+
+	log.Debug("get api key")
+
+	key := models.APIKey{}
+	if err = pgsql.Conn(ctx, r.db).QueryRowxContext(ctx, sqlStr, args...).StructScan(&key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, models.ErrNotFound
+		}
+		log.WithError(err).Error("get api key")
+		return models.APIKey{}, fmt.Errorf("get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeysRepository) GetAPIKeys(ctx context.Context) (models.APIKeys, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "GetAPIKeys",
+	})
+
+	query := sq.Select(apiKeyColumns...).From("api_keys").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sql)
+
+	log.Debug("get api keys")
+
+	rows, err := pgsql.Conn(ctx, r.db).QueryxContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("get api keys")
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := models.APIKeys{}
+
+	for rows.Next() {
+		key := models.APIKey{}
+
+		if err = rows.StructScan(&key); err != nil {
+			log.WithError(err).Error("scan api key")
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.WithError(err).Error("get api keys")
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeysRepository) RevokeAPIKey(ctx context.Context, id int64, at time.Time) error {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "RevokeAPIKey",
+		"id":    id,
+	})
+
+	query := sq.Update("api_keys").
+		Set("revoked_at", at).
+		Where(sq.Eq{"id": id, "revoked_at": nil}).
+		PlaceholderFormat(sq.Dollar)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sql)
+
+	log.Debug("revoke api key")
+
+	res, err := pgsql.Conn(ctx, r.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("revoke api key")
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *apiKeysRepository) TouchAPIKey(ctx context.Context, id int64, at time.Time) error {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "TouchAPIKey",
+		"id":    id,
+	})
+
+	query := sq.Update("api_keys").
+		Set("last_used_at", at).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return fmt.Errorf("to sql: %w", err)
+	}
+
+	if _, err = pgsql.Conn(ctx, r.db).ExecContext(ctx, sql, args...); err != nil {
+		log.WithError(err).Error("touch api key")
+		return fmt.Errorf("touch api key: %w", err)
+	}
+
+	return nil
+}
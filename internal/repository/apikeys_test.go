@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/moguchev/service/internal/models"
+)
+
+func TestCreateAPIKey_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	created := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, created)
+	mock.ExpectQuery("INSERT INTO api_keys (.+) RETURNING id, created_at").
+		WithArgs("batch", "sk_prefix", "hash", "admin employees:read", nil).
+		WillReturnRows(rows)
+
+	repo := NewAPIKeysRepository(db)
+	key, err := repo.CreateAPIKey(context.Background(), models.APIKey{
+		Name:   "batch",
+		Prefix: "sk_prefix",
+		Scopes: models.Scopes{models.ScopeAdmin, models.ScopeEmployeesRead},
+	}, "hash")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if key.ID != 1 || !key.CreatedAt.Equal(created) {
+		t.Errorf("func returned unexpected value: %v", key)
+	}
+}
+
+func TestGetAPIKeyByHash_Success(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	created := time.Now()
+	rows := sqlmock.NewRows(apiKeyColumns).AddRow(1, "batch", "sk_prefix", "admin", nil, created, nil, nil)
+	mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE key_hash = (.+)").WithArgs("hash").WillReturnRows(rows)
+
+	repo := NewAPIKeysRepository(db)
+	key, err := repo.GetAPIKeyByHash(context.Background(), "hash")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := models.APIKey{ID: 1, Name: "batch", Prefix: "sk_prefix", Scopes: models.Scopes{"admin"}, CreatedAt: created}
+	if !reflect.DeepEqual(key, expected) {
+		t.Errorf("expected: %v, got: %v", expected, key)
+	}
+}
+
+func TestGetAPIKeyByHash_NotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectQuery("SELECT (.+) FROM api_keys").WillReturnRows(sqlmock.NewRows(apiKeyColumns))
+
+	repo := NewAPIKeysRepository(db)
+	_, err = repo.GetAPIKeyByHash(context.Background(), "hash")
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected error: %v, got: %v", models.ErrNotFound, err)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectExec("UPDATE api_keys SET revoked_at (.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE api_keys SET revoked_at (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE api_keys SET revoked_at (.+)").WillReturnError(fmt.Errorf("error"))
+
+	repo := NewAPIKeysRepository(db)
+	if err = repo.RevokeAPIKey(context.Background(), 1, time.Now()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err = repo.RevokeAPIKey(context.Background(), 1, time.Now()); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("expected error: %v, got: %v", models.ErrNotFound, err)
+	}
+
+	if err = repo.RevokeAPIKey(context.Background(), 1, time.Now()); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetAPIKeys_RowsError(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	created := time.Now()
+	rows := sqlmock.NewRows(apiKeyColumns).
+		AddRow(1, "batch", "sk_prefix", "admin", nil, created, nil, nil).
+		AddRow(2, "other", "sk_other", "admin", nil, created, nil, nil).
+		RowError(1, errors.New("connection reset"))
+	mock.ExpectQuery("SELECT (.+) FROM api_keys ORDER BY id").WillReturnRows(rows)
+
+	repo := NewAPIKeysRepository(db)
+	if keys, err := repo.GetAPIKeys(context.Background()); err == nil {
+		t.Errorf("expected error instead of truncated list: %v", keys)
+	}
+}
@@ -9,16 +9,24 @@ import (
 	"github.com/moguchev/service/internal/employees"
 	"github.com/moguchev/service/internal/models"
 	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/pgsql"
 	"github.com/sirupsen/logrus"
 )
 
 type employeesRepository struct {
-	db *sqlx.DB
+	// reader returns connection for read-only queries outside of transactions
+	reader func() *sqlx.DB
 }
 
 // NewEmployeesRepository will create an object that represent the employees.Repository interface
 func NewEmployeesRepository(db *sqlx.DB) employees.Repository {
-	return &employeesRepository{db: db}
+	return &employeesRepository{reader: func() *sqlx.DB { return db }}
+}
+
+// NewEmployeesClusterRepository will create an object that represent the employees.Repository interface
+// reading from replicas of cluster
+func NewEmployeesClusterRepository(cluster *pgsql.Cluster) employees.Repository {
+	return &employeesRepository{reader: cluster.Replica}
 }
 
 func applyEmployeeFilter(sb sq.SelectBuilder, f models.EmployeeFilter) sq.SelectBuilder {
@@ -69,13 +77,8 @@ func applyEmployeeFilter(sb sq.SelectBuilder, f models.EmployeeFilter) sq.Select
 	return sb
 }
 
-func (r *employeesRepository) CountEmployees(ctx context.Context, f models.EmployeeFilter) (uint, error) {
-	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
-		"actor":  "repository",
-		"func":   "CountEmployees",
-		"filter": f,
-	})
-
+// countEmployeesQuery - query counting employees matching filter, pagination and sorting are ignored
+func countEmployeesQuery(f models.EmployeeFilter) (string, []interface{}, error) {
 	query := sq.Select("COUNT(employee_id)").From("employees").
 		Join("salaries ON employees.assignment_id = salaries.assignment_id").
 		PlaceholderFormat(sq.Dollar)
@@ -85,9 +88,29 @@ func (r *employeesRepository) CountEmployees(ctx context.Context, f models.Emplo
 	f.SalarySort = nil
 	f.DateFromSort = nil
 
-	query = applyEmployeeFilter(query, f)
+	return applyEmployeeFilter(query, f).ToSql()
+}
+
+// getEmployeesQuery - query selecting employees in order of models.Employee fields
+func getEmployeesQuery(f models.EmployeeFilter) (string, []interface{}, error) {
+	cols := []string{"employees.employee_id", "employees.assignment_id", "employees.fio",
+		"employees.job_name", "salaries.salary", "salaries.date_from"}
+
+	query := sq.Select(cols...).From("employees").
+		Join("salaries ON employees.assignment_id = salaries.assignment_id").
+		PlaceholderFormat(sq.Dollar)
+
+	return applyEmployeeFilter(query, f).ToSql()
+}
+
+func (r *employeesRepository) CountEmployees(ctx context.Context, f models.EmployeeFilter) (uint, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor":  "repository",
+		"func":   "CountEmployees",
+		"filter": f,
+	})
 
-	sql, args, err := query.ToSql()
+	sql, args, err := countEmployeesQuery(f)
 	if err != nil {
 		return 0, fmt.Errorf("to sql: %w", err)
 	}
@@ -97,7 +120,7 @@ func (r *employeesRepository) CountEmployees(ctx context.Context, f models.Emplo
 	log.Debug("count employees")
 
 	var count uint
-	if err = r.db.QueryRowxContext(ctx, sql, args...).Scan(&count); err != nil {
+	if err = pgsql.Conn(ctx, r.reader()).QueryRowxContext(ctx, sql, args...).Scan(&count); err != nil {
 		log.WithError(err).Error("count employees")
 		return 0, fmt.Errorf("count employees: %w", err)
 	}
@@ -111,16 +134,7 @@ func (r *employeesRepository) GetEmployees(ctx context.Context, f models.Employe
 		"func":  "GetEmployees",
 	})
 
-	cols := []string{"employees.employee_id", "employees.assignment_id", "employees.fio",
-		"employees.job_name", "salaries.salary", "salaries.date_from"}
-
-	query := sq.Select(cols...).From("employees").
-		Join("salaries ON employees.assignment_id = salaries.assignment_id").
-		PlaceholderFormat(sq.Dollar)
-
-	query = applyEmployeeFilter(query, f)
-
-	sql, args, err := query.ToSql()
+	sql, args, err := getEmployeesQuery(f)
 	if err != nil {
 		return nil, fmt.Errorf("to sql: %w", err)
 	}
@@ -129,7 +143,7 @@ func (r *employeesRepository) GetEmployees(ctx context.Context, f models.Employe
 
 	log.Debug("get employees")
 
-	rows, err := r.db.QueryxContext(ctx, sql, args...)
+	rows, err := pgsql.Conn(ctx, r.reader()).QueryxContext(ctx, sql, args...)
 	if err != nil {
 		log.WithError(err).Error("get employees")
 		return nil, fmt.Errorf("get employees: %w", err)
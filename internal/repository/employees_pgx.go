@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/moguchev/service/internal/employees"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// pgxQuerier - part of pgxpool.Pool used by repository
+type pgxQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type employeesPgxRepository struct {
+	pool pgxQuerier
+}
+
+// NewEmployeesPgxRepository will create an object that represent the employees.Repository interface
+// on top of native pgx pool
+func NewEmployeesPgxRepository(pool *pgxpool.Pool) employees.Repository {
+	return &employeesPgxRepository{pool: pool}
+}
+
+func (r *employeesPgxRepository) CountEmployees(ctx context.Context, f models.EmployeeFilter) (uint, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor":  "repository",
+		"func":   "CountEmployees",
+		"filter": f,
+	})
+
+	sql, args, err := countEmployeesQuery(f)
+	if err != nil {
+		return 0, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithFields(logrus.Fields{"query": sql, "args": args})
+
+	log.Debug("count employees")
+
+	var count int64
+	if err = r.pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		log.WithError(err).Error("count employees")
+		return 0, fmt.Errorf("count employees: %w", err)
+	}
+
+	return uint(count), nil
+}
+
+func (r *employeesPgxRepository) GetEmployees(ctx context.Context, f models.EmployeeFilter) (models.Employees, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "GetEmployees",
+	})
+
+	sql, args, err := getEmployeesQuery(f)
+	if err != nil {
+		return nil, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithFields(logrus.Fields{"query": sql, "args": args})
+
+	log.Debug("get employees")
+
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		log.WithError(err).Error("get employees")
+		return nil, fmt.Errorf("get employees: %w", err)
+	}
+	defer rows.Close()
+
+	emps := models.Employees{}
+
+	for rows.Next() {
+		var (
+			employee = models.Employee{}
+			// salary is integer column, pgx does not convert it to float
+			salary *int64
+		)
+
+		err = rows.Scan(&employee.EmployeeID, &employee.AssignmentID, &employee.FIO,
+			&employee.JobName, &salary, &employee.DateFrom)
+		if err != nil {
+			log.WithError(err).Error("scan employee")
+			return nil, fmt.Errorf("scan employee: %w", err)
+		}
+
+		if salary != nil {
+			s := float64(*salary)
+			employee.Salary = &s
+		}
+
+		emps = append(emps, employee)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.WithError(err).Error("get employees")
+		return nil, fmt.Errorf("get employees: %w", err)
+	}
+
+	return emps, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/moguchev/service/internal/models"
+)
+
+// fakeRows - pgx.Rows returning given values, unused methods panic
+type fakeRows struct {
+	pgx.Rows
+	values [][]interface{}
+	err    error
+}
+
+func (r *fakeRows) Close() {}
+
+func (r *fakeRows) Err() error { return r.err }
+
+func (r *fakeRows) Next() bool {
+	return len(r.values) > 0
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	row := r.values[0]
+	r.values = r.values[1:]
+	if len(dest) != len(row) {
+		return errors.New("unexpected number of columns")
+	}
+	for i := range dest {
+		if row[i] != nil {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(row[i]))
+		}
+	}
+	return nil
+}
+
+type fakeQuerier struct {
+	rows     *fakeRows
+	err      error
+	lastSQL  string
+	lastArgs []interface{}
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	q.lastSQL, q.lastArgs = sql, args
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.rows, nil
+}
+
+func (q *fakeQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	q.lastSQL, q.lastArgs = sql, args
+	return q.rows
+}
+
+func TestPgxCountEmployees(t *testing.T) {
+	q := &fakeQuerier{rows: &fakeRows{values: [][]interface{}{{int64(3)}}}}
+	repo := &employeesPgxRepository{pool: q}
+
+	fio := "Ivan"
+	count, err := repo.CountEmployees(context.Background(), models.EmployeeFilter{FIO: &fio})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3, got %d", count)
+	}
+
+	expected := "SELECT COUNT(employee_id) FROM employees JOIN salaries ON employees.assignment_id = salaries.assignment_id WHERE (employees.fio ILIKE $1)"
+	if q.lastSQL != expected {
+		t.Errorf("unexpected query: got %v want %v", q.lastSQL, expected)
+	}
+}
+
+func TestPgxGetEmployees(t *testing.T) {
+	var (
+		salary   int64 = 100000
+		expected       = float64(salary)
+		date           = time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	)
+
+	q := &fakeQuerier{rows: &fakeRows{values: [][]interface{}{
+		{int64(1), int64(10), "Иванов Иван Иванович", "Инженер", &salary, &date},
+		{int64(2), int64(11), "John Smith", "Engineer", nil, nil},
+	}}}
+	repo := &employeesPgxRepository{pool: q}
+
+	emps, err := repo.GetEmployees(context.Background(), models.EmployeeFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := models.Employees{
+		{EmployeeID: 1, AssignmentID: 10, FIO: "Иванов Иван Иванович", JobName: "Инженер", Salary: &expected, DateFrom: &date},
+		{EmployeeID: 2, AssignmentID: 11, FIO: "John Smith", JobName: "Engineer"},
+	}
+	if !reflect.DeepEqual(emps, want) {
+		t.Errorf("unexpected employees: got %+v want %+v", emps, want)
+	}
+}
+
+func TestPgxGetEmployees_Error(t *testing.T) {
+	repo := &employeesPgxRepository{pool: &fakeQuerier{err: errors.New("connection refused")}}
+
+	if _, err := repo.GetEmployees(context.Background(), models.EmployeeFilter{}); err == nil {
+		t.Errorf("expected error")
+	}
+
+	repo = &employeesPgxRepository{pool: &fakeQuerier{rows: &fakeRows{err: errors.New("conn closed")}}}
+
+	if _, err := repo.GetEmployees(context.Background(), models.EmployeeFilter{}); err == nil {
+		t.Errorf("expected rows error")
+	}
+}
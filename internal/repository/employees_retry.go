@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/moguchev/service/internal/employees"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/pgsql"
+)
+
+type employeesRetryRepository struct {
+	next    employees.Repository
+	retrier *pgsql.Retrier
+}
+
+// NewEmployeesRetryRepository will create an object that represent the employees.Repository interface
+// repeating reads of next repository failed by connection errors
+func NewEmployeesRetryRepository(next employees.Repository, retrier *pgsql.Retrier) employees.Repository {
+	return &employeesRetryRepository{next: next, retrier: retrier}
+}
+
+func (r *employeesRetryRepository) CountEmployees(ctx context.Context, f models.EmployeeFilter) (count uint, err error) {
+	err = r.retrier.Do(ctx, func(ctx context.Context) error {
+		count, err = r.next.CountEmployees(ctx, f)
+		return err
+	})
+	return count, unavailable(err)
+}
+
+func (r *employeesRetryRepository) GetEmployees(ctx context.Context, f models.EmployeeFilter) (emps models.Employees, err error) {
+	err = r.retrier.Do(ctx, func(ctx context.Context) error {
+		emps, err = r.next.GetEmployees(ctx, f)
+		return err
+	})
+	return emps, unavailable(err)
+}
+
+// unavailable marks error of open circuit so that it is not reported as internal one
+func unavailable(err error) error {
+	if errors.Is(err, pgsql.ErrCircuitOpen) {
+		return fmt.Errorf("%w: %v", models.ErrUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/internal/users"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/pgsql"
+	"github.com/sirupsen/logrus"
+)
+
+const uniqueViolation = "23505"
+
+var (
+	userColumns         = []string{"id", "login", "password_hash", "scopes", "created_at"}
+	refreshTokenColumns = []string{"id", "user_id", "expires_at", "created_at", "revoked_at"}
+)
+
+type usersRepository struct {
+	db *sqlx.DB
+}
+
+// NewUsersRepository will create an object that represent the users.Repository interface
+func NewUsersRepository(db *sqlx.DB) users.Repository {
+	return &usersRepository{db: db}
+}
+
+func (r *usersRepository) CreateUser(ctx context.Context, u models.User) (models.User, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "CreateUser",
+		"login": u.Login,
+	})
+
+	query := sq.Insert("users").
+		Columns("login", "password_hash", "scopes").
+		Values(u.Login, u.PasswordHash, u.Scopes).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.User{}, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sqlStr)
+
+	log.Debug("create user")
+
+	if err = pgsql.Conn(ctx, r.db).QueryRowxContext(ctx, sqlStr, args...).Scan(&u.ID, &u.CreatedAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return models.User{}, models.ErrAlreadyExists
+		}
+		log.WithError(err).Error("create user")
+		return models.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *usersRepository) getUser(ctx context.Context, log *logrus.Entry, where sq.Eq) (models.User, error) {
+	query := sq.Select(userColumns...).From("users").
+		Where(where).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.User{}, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sqlStr)
+
+	log.Debug("get user")
+
+	u := models.User{}
+	if err = pgsql.Conn(ctx, r.db).QueryRowxContext(ctx, sqlStr, args...).StructScan(&u); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, models.ErrNotFound
+		}
+		log.WithError(err).Error("get user")
+		return models.User{}, fmt.Errorf("get user: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *usersRepository) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "GetUserByLogin",
+		"login": login,
+	})
+
+	return r.getUser(ctx, log, sq.Eq{"login": login})
+}
+
+func (r *usersRepository) GetUserByID(ctx context.Context, id int64) (models.User, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "GetUserByID",
+		"id":    id,
+	})
+
+	return r.getUser(ctx, log, sq.Eq{"id": id})
+}
+
+func (r *usersRepository) CreateRefreshToken(ctx context.Context, t models.RefreshToken, hash string) (models.RefreshToken, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor":   "repository",
+		"func":    "CreateRefreshToken",
+		"user_id": t.UserID,
+	})
+
+	query := sq.Insert("refresh_tokens").
+		Columns("user_id", "token_hash", "expires_at").
+		Values(t.UserID, hash, t.ExpiresAt).
+		Suffix("RETURNING id, created_at").
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.RefreshToken{}, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sqlStr)
+
+	log.Debug("create refresh token")
+
+	if err = pgsql.Conn(ctx, r.db).QueryRowxContext(ctx, sqlStr, args...).Scan(&t.ID, &t.CreatedAt); err != nil {
+		log.WithError(err).Error("create refresh token")
+		return models.RefreshToken{}, fmt.Errorf("create refresh token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *usersRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "GetRefreshTokenByHash",
+	})
+
+	query := sq.Select(refreshTokenColumns...).From("refresh_tokens").
+		Where(sq.Eq{"token_hash": hash}).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return models.RefreshToken{}, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sqlStr)
+
+	log.Debug("get refresh token")
+
+	t := models.RefreshToken{}
+	if err = pgsql.Conn(ctx, r.db).QueryRowxContext(ctx, sqlStr, args...).StructScan(&t); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RefreshToken{}, models.ErrNotFound
+		}
+		log.WithError(err).Error("get refresh token")
+		return models.RefreshToken{}, fmt.Errorf("get refresh token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *usersRepository) revokeRefreshTokens(ctx context.Context, log *logrus.Entry, where sq.Eq, at time.Time) (int64, error) {
+	where["revoked_at"] = nil
+
+	query := sq.Update("refresh_tokens").
+		Set("revoked_at", at).
+		Where(where).
+		PlaceholderFormat(sq.Dollar)
+
+	sqlStr, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("to sql: %w", err)
+	}
+
+	log = log.WithField("query", sqlStr)
+
+	log.Debug("revoke refresh tokens")
+
+	res, err := pgsql.Conn(ctx, r.db).ExecContext(ctx, sqlStr, args...)
+	if err != nil {
+		log.WithError(err).Error("revoke refresh tokens")
+		return 0, fmt.Errorf("revoke refresh tokens: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return affected, nil
+}
+
+// RevokeRefreshToken returns models.ErrNotFound if token is unknown or already revoked
+func (r *usersRepository) RevokeRefreshToken(ctx context.Context, id int64, at time.Time) error {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "repository",
+		"func":  "RevokeRefreshToken",
+		"id":    id,
+	})
+
+	affected, err := r.revokeRefreshTokens(ctx, log, sq.Eq{"id": id}, at)
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *usersRepository) RevokeUserRefreshTokens(ctx context.Context, userID int64, at time.Time) error {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor":   "repository",
+		"func":    "RevokeUserRefreshTokens",
+		"user_id": userID,
+	})
+
+	_, err := r.revokeRefreshTokens(ctx, log, sq.Eq{"user_id": userID}, at)
+	return err
+}
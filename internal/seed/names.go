@@ -0,0 +1,92 @@
+package seed
+
+import (
+	"math/rand"
+	"strings"
+)
+
+var (
+	ruMaleNames = []string{
+		"Александр", "Алексей", "Андрей", "Антон", "Артём", "Борис", "Вадим", "Василий",
+		"Виктор", "Владимир", "Дмитрий", "Евгений", "Иван", "Игорь", "Кирилл", "Константин",
+		"Леонид", "Максим", "Михаил", "Никита", "Николай", "Олег", "Павел", "Роман",
+		"Сергей", "Степан", "Фёдор", "Юрий",
+	}
+	ruFemaleNames = []string{
+		"Александра", "Алина", "Анастасия", "Анна", "Валентина", "Вера", "Виктория", "Галина",
+		"Дарья", "Екатерина", "Елена", "Ирина", "Ксения", "Лариса", "Людмила", "Марина",
+		"Мария", "Наталья", "Ольга", "Полина", "Светлана", "Софья", "Татьяна", "Юлия",
+	}
+	// ruPatronymics - male and female forms
+	ruPatronymics = [][2]string{
+		{"Александрович", "Александровна"}, {"Алексеевич", "Алексеевна"}, {"Андреевич", "Андреевна"},
+		{"Борисович", "Борисовна"}, {"Викторович", "Викторовна"}, {"Владимирович", "Владимировна"},
+		{"Дмитриевич", "Дмитриевна"}, {"Евгеньевич", "Евгеньевна"}, {"Иванович", "Ивановна"},
+		{"Игоревич", "Игоревна"}, {"Михайлович", "Михайловна"}, {"Николаевич", "Николаевна"},
+		{"Олегович", "Олеговна"}, {"Павлович", "Павловна"}, {"Петрович", "Петровна"},
+		{"Сергеевич", "Сергеевна"}, {"Юрьевич", "Юрьевна"},
+	}
+	// ruSurnames - male forms, female ones are derived by ruFemaleSurname
+	ruSurnames = []string{
+		"Иванов", "Смирнов", "Кузнецов", "Попов", "Васильев", "Петров", "Соколов", "Михайлов",
+		"Новиков", "Фёдоров", "Морозов", "Волков", "Алексеев", "Лебедев", "Семёнов", "Егоров",
+		"Павлов", "Козлов", "Степанов", "Николаев", "Орлов", "Андреев", "Макаров", "Никитин",
+		"Захаров", "Зайцев", "Соловьёв", "Борисов", "Яковлев", "Григорьев", "Романов", "Воробьёв",
+		"Калинин", "Ильин", "Голубев", "Ковалевский", "Вишневский", "Островский", "Шевченко", "Бондаренко",
+	}
+
+	enMaleNames = []string{
+		"James", "John", "Robert", "Michael", "William", "David", "Richard", "Joseph",
+		"Thomas", "Charles", "Daniel", "Matthew", "Anthony", "Mark", "Steven", "Paul",
+		"Andrew", "Joshua", "Kevin", "Brian", "George", "Edward", "Ryan", "Jacob",
+	}
+	enFemaleNames = []string{
+		"Mary", "Patricia", "Jennifer", "Linda", "Elizabeth", "Barbara", "Susan", "Jessica",
+		"Sarah", "Karen", "Nancy", "Lisa", "Betty", "Margaret", "Sandra", "Ashley",
+		"Emily", "Donna", "Michelle", "Carol", "Amanda", "Melissa", "Deborah", "Laura",
+	}
+	enSurnames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+		"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Taylor", "Thomas",
+		"Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark",
+		"Lewis", "Robinson", "Walker", "Young", "Allen", "King", "Wright", "Scott",
+	}
+)
+
+func ruMale(r *rand.Rand) person {
+	return person{
+		first:  pick(r, ruMaleNames),
+		middle: ruPatronymics[r.Intn(len(ruPatronymics))][0],
+		last:   pick(r, ruSurnames),
+	}
+}
+
+func ruFemale(r *rand.Rand) person {
+	return person{
+		first:  pick(r, ruFemaleNames),
+		middle: ruPatronymics[r.Intn(len(ruPatronymics))][1],
+		last:   ruFemaleSurname(pick(r, ruSurnames)),
+	}
+}
+
+// ruFemaleSurname returns female form of russian surname
+func ruFemaleSurname(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ский"):
+		return strings.TrimSuffix(s, "ий") + "ая"
+	case strings.HasSuffix(s, "ов"), strings.HasSuffix(s, "ев"),
+		strings.HasSuffix(s, "ёв"), strings.HasSuffix(s, "ин"):
+		return s + "а"
+	default:
+		// surnames like Шевченко are the same for both genders
+		return s
+	}
+}
+
+func enMale(r *rand.Rand) person {
+	return person{first: pick(r, enMaleNames), last: pick(r, enSurnames)}
+}
+
+func enFemale(r *rand.Rand) person {
+	return person{first: pick(r, enFemaleNames), last: pick(r, enSurnames)}
+}
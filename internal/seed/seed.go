@@ -0,0 +1,184 @@
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+)
+
+const (
+	// locales of generated names and jobs
+	RU  = "ru"
+	EN  = "en"
+	Mix = "mix"
+
+	// maxAssignments - max number of assignments in employee history
+	maxAssignments = 4
+	// historyYears - how far back history of the oldest employees goes
+	historyYears = 15
+)
+
+// Options - parameters of generated dataset, the same options give the same dataset
+type Options struct {
+	Employees int
+	Seed      int64
+	Locale    string // enum (ru|en|mix)
+	// Until - date of the latest possible assignment
+	Until time.Time
+	// FirstEmployeeID, FirstAssignmentID - ids are generated sequentially from them
+	FirstEmployeeID   int64
+	FirstAssignmentID int64
+}
+
+type person struct {
+	first, middle, last string
+}
+
+type job struct {
+	name   string
+	salary float64 // median monthly salary
+}
+
+type locale struct {
+	male, female func(r *rand.Rand) person
+	format       func(p person) string
+	jobs         []job
+}
+
+var locales = map[string]locale{
+	RU: {
+		male:   ruMale,
+		female: ruFemale,
+		format: func(p person) string { return p.last + " " + p.first + " " + p.middle },
+		jobs: []job{
+			{"Инженер-программист", 150000},
+			{"Ведущий инженер-программист", 220000},
+			{"Бухгалтер", 70000},
+			{"Главный бухгалтер", 140000},
+			{"Менеджер по продажам", 80000},
+			{"Специалист по кадрам", 65000},
+			{"Системный администратор", 95000},
+			{"Аналитик", 120000},
+			{"Руководитель отдела", 250000},
+			{"Юрист", 110000},
+			{"Инженер по тестированию", 110000},
+			{"Офис-менеджер", 50000},
+		},
+	},
+	EN: {
+		male:   enMale,
+		female: enFemale,
+		format: func(p person) string { return p.first + " " + p.last },
+		jobs: []job{
+			{"Software Engineer", 150000},
+			{"Senior Software Engineer", 220000},
+			{"Accountant", 70000},
+			{"Chief Accountant", 140000},
+			{"Sales Manager", 80000},
+			{"HR Specialist", 65000},
+			{"System Administrator", 95000},
+			{"Business Analyst", 120000},
+			{"Head of Department", 250000},
+			{"Lawyer", 110000},
+			{"QA Engineer", 110000},
+			{"Office Manager", 50000},
+		},
+	},
+}
+
+// Generate returns employees with their assignment histories, one item per assignment.
+// Assignments of an employee follow each other in time with growing salary,
+// some employees additionally hold a concurrent part-time assignment.
+func Generate(opts Options) (models.Employees, error) {
+	if opts.Employees < 0 {
+		return nil, fmt.Errorf("number of employees must not be negative, got %d", opts.Employees)
+	}
+
+	if opts.Locale == "" {
+		opts.Locale = Mix
+	}
+	if _, ok := locales[opts.Locale]; !ok && opts.Locale != Mix {
+		return nil, fmt.Errorf("unknown locale %q", opts.Locale)
+	}
+
+	if opts.Until.IsZero() {
+		return nil, fmt.Errorf("until date is required to generate reproducible dates")
+	}
+	until := opts.Until.UTC().Truncate(24 * time.Hour)
+
+	r := rand.New(rand.NewSource(opts.Seed))
+
+	employeeID, assignmentID := opts.FirstEmployeeID, opts.FirstAssignmentID
+	if employeeID == 0 {
+		employeeID = 1
+	}
+	if assignmentID == 0 {
+		assignmentID = 1
+	}
+
+	list := make(models.Employees, 0, opts.Employees*2)
+	for i := 0; i < opts.Employees; i++ {
+		loc := locales[opts.Locale]
+		if opts.Locale == Mix {
+			// mostly russian names as in production data
+			loc = locales[RU]
+			if r.Intn(5) == 0 {
+				loc = locales[EN]
+			}
+		}
+
+		p := loc.male(r)
+		if r.Intn(2) == 0 {
+			p = loc.female(r)
+		}
+		fio := loc.format(p)
+
+		// history starts at random day and moves forward by 1-4 years per assignment
+		date := until.AddDate(-r.Intn(historyYears), 0, -r.Intn(365))
+		grade := r.Intn(len(loc.jobs))
+		salary := loc.jobs[grade].salary * (0.8 + 0.4*r.Float64())
+
+		for n := 1 + r.Intn(maxAssignments); n > 0 && !date.After(until); n-- {
+			list = append(list, assignment(employeeID, assignmentID, fio, loc.jobs[grade].name, salary, date))
+			assignmentID++
+
+			date = date.AddDate(1+r.Intn(3), r.Intn(12), 0)
+			salary *= 1.05 + 0.15*r.Float64()
+			// promotion to the next job in list is a rough approximation of a career
+			if r.Intn(3) == 0 && grade+1 < len(loc.jobs) {
+				grade++
+			}
+		}
+
+		if r.Intn(10) == 0 {
+			part := loc.jobs[r.Intn(len(loc.jobs))]
+			date = until.AddDate(0, -r.Intn(24), -r.Intn(28))
+			list = append(list, assignment(employeeID, assignmentID, fio, part.name, part.salary/2, date))
+			assignmentID++
+		}
+
+		employeeID++
+	}
+
+	return list, nil
+}
+
+func assignment(employeeID, assignmentID int64, fio, jobName string, salary float64, date time.Time) models.Employee {
+	// salaries are whole thousands as in payroll
+	salary = math.Round(salary/1000) * 1000
+	return models.Employee{
+		EmployeeID:   employeeID,
+		AssignmentID: assignmentID,
+		FIO:          fio,
+		JobName:      jobName,
+		Salary:       &salary,
+		DateFrom:     &date,
+	}
+}
+
+func pick(r *rand.Rand, list []string) string {
+	return list[r.Intn(len(list))]
+}
@@ -0,0 +1,91 @@
+package seed
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	opts := Options{
+		Employees:         100,
+		Seed:              42,
+		Until:             time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC),
+		FirstEmployeeID:   10,
+		FirstAssignmentID: 20,
+	}
+
+	list, err := Generate(opts)
+	assert.NoError(t, err)
+
+	again, err := Generate(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, list, again, "the same options must give the same data")
+
+	assert.True(t, len(list) >= opts.Employees)
+
+	assignments := map[int64]bool{}
+	last := map[int64]models.Employee{}
+	for _, e := range list {
+		assert.False(t, assignments[e.AssignmentID], "duplicate assignment %d", e.AssignmentID)
+		assignments[e.AssignmentID] = true
+
+		assert.True(t, e.EmployeeID >= 10 && e.EmployeeID < 110, "employee id %d", e.EmployeeID)
+		assert.True(t, e.AssignmentID >= 20)
+		assert.NotEmpty(t, e.FIO)
+		assert.NotEmpty(t, e.JobName)
+		assert.True(t, *e.Salary > 0)
+		assert.False(t, e.DateFrom.After(opts.Until))
+
+		if prev, ok := last[e.EmployeeID]; ok {
+			assert.Equal(t, prev.FIO, e.FIO)
+		}
+		last[e.EmployeeID] = e
+	}
+	assert.Len(t, last, opts.Employees)
+
+	opts.Seed = 43
+	other, err := Generate(opts)
+	assert.NoError(t, err)
+	assert.NotEqual(t, list, other)
+
+	_, err = Generate(Options{Employees: 1, Until: opts.Until, Locale: "de"})
+	assert.Error(t, err)
+
+	_, err = Generate(Options{Employees: 1})
+	assert.Error(t, err)
+}
+
+func TestRuFemaleSurname(t *testing.T) {
+	assert.Equal(t, "Иванова", ruFemaleSurname("Иванов"))
+	assert.Equal(t, "Соловьёва", ruFemaleSurname("Соловьёв"))
+	assert.Equal(t, "Островская", ruFemaleSurname("Островский"))
+	assert.Equal(t, "Шевченко", ruFemaleSurname("Шевченко"))
+}
+
+func TestWriteFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	list, err := Generate(Options{Employees: 5, Until: time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC), Locale: EN})
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "fixtures.json")
+	assert.NoError(t, WriteFixtures(path, list))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	read := models.Employees{}
+	assert.NoError(t, json.Unmarshal(data, &read))
+	assert.Equal(t, list, read)
+}
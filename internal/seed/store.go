@@ -0,0 +1,80 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/moguchev/service/internal/models"
+)
+
+// NextIDs returns ids following the existing ones, so that seed can be added to existing data
+func NextIDs(ctx context.Context, conn *pgx.Conn) (employeeID, assignmentID int64, err error) {
+	err = conn.QueryRow(ctx, `SELECT COALESCE(MAX(employee_id), 0) + 1, COALESCE(MAX(assignment_id), 0) + 1
+		FROM employees`).Scan(&employeeID, &assignmentID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get max ids: %w", err)
+	}
+	return employeeID, assignmentID, nil
+}
+
+// Insert copies assignments into employees and salaries tables in one transaction.
+// COPY is used instead of INSERT as datasets of millions rows are expected.
+func Insert(ctx context.Context, conn *pgx.Conn, list models.Employees, truncate bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck // no-op after commit
+
+	if truncate {
+		if _, err = tx.Exec(ctx, "TRUNCATE employees, salaries"); err != nil {
+			return fmt.Errorf("truncate: %w", err)
+		}
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"employees"},
+		[]string{"assignment_id", "employee_id", "fio", "job_name"},
+		pgx.CopyFromSlice(len(list), func(i int) ([]interface{}, error) {
+			e := list[i]
+			return []interface{}{e.AssignmentID, e.EmployeeID, e.FIO, e.JobName}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("copy employees: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"salaries"},
+		[]string{"assignment_id", "salary", "date_from"},
+		pgx.CopyFromSlice(len(list), func(i int) ([]interface{}, error) {
+			e := list[i]
+			return []interface{}{e.AssignmentID, int32(*e.Salary), *e.DateFrom}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("copy salaries: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFixtures writes assignments to file as JSON array in the API format
+func WriteFixtures(path string, list models.Employees) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(list); err != nil {
+		f.Close()
+		return fmt.Errorf("encode fixtures: %w", err)
+	}
+
+	return f.Close()
+}
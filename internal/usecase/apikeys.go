@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moguchev/service/internal/apikeys"
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	apiKeyPrefix    = "sk_"
+	apiKeyPrefixLen = 8
+	apiKeySecretLen = 32
+
+	rootSubject = "root"
+)
+
+type apiKeysUsecase struct {
+	keyRepo apikeys.Repository
+	rootKey string
+	now     func() time.Time
+}
+
+// NewAPIKeysUsecase will create new an apiKeysUsecase object representation of apikeys.Usecase interface.
+// rootKey (if not empty) is accepted as a key with admin scope and lets to issue the first keys.
+func NewAPIKeysUsecase(kRepo apikeys.Repository, rootKey string) apikeys.Usecase {
+	return &apiKeysUsecase{keyRepo: kRepo, rootKey: rootKey, now: time.Now}
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, apiKeySecretLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (a *apiKeysUsecase) IssueAPIKey(ctx context.Context, req models.NewAPIKey) (models.APIKey, string, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.IssueAPIKey")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "IssueAPIKey",
+		"name":  req.Name,
+	})
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(a.now()) {
+		return models.APIKey{}, "", fmt.Errorf("%w: expires_at must be in the future", models.ErrInvalidArgument)
+	}
+
+	plain, err := generateAPIKey()
+	if err != nil {
+		log.WithError(err).Error("generate api key")
+		return models.APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	key := models.APIKey{
+		Name:      req.Name,
+		Prefix:    plain[:len(apiKeyPrefix)+apiKeyPrefixLen],
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	key, err = a.keyRepo.CreateAPIKey(ctx, key, hashAPIKey(plain))
+	if err != nil {
+		log.WithError(err).Error("create api key")
+		return models.APIKey{}, "", fmt.Errorf("create api key: %w", err)
+	}
+
+	log.WithField("id", key.ID).Info("api key issued")
+
+	return key, plain, nil
+}
+
+func (a *apiKeysUsecase) GetAPIKeys(ctx context.Context) (models.APIKeys, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.GetAPIKeys")
+	defer span.End()
+
+	keys, err := a.keyRepo.GetAPIKeys(ctx)
+	if err != nil {
+		logger.GetLogger(ctx).WithFields(logrus.Fields{
+			"actor": "usecase",
+			"func":  "GetAPIKeys",
+		}).WithError(err).Error("get api keys")
+		return nil, fmt.Errorf("get api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a *apiKeysUsecase) RevokeAPIKey(ctx context.Context, id int64) error {
+	ctx, span := tracing.StartSpan(ctx, "usecase.RevokeAPIKey")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "RevokeAPIKey",
+		"id":    id,
+	})
+
+	if err := a.keyRepo.RevokeAPIKey(ctx, id, a.now()); err != nil {
+		if !errors.Is(err, models.ErrNotFound) {
+			log.WithError(err).Error("revoke api key")
+		}
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	log.Info("api key revoked")
+
+	return nil
+}
+
+func (a *apiKeysUsecase) AuthenticateAPIKey(ctx context.Context, key string) (auth.Principal, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.AuthenticateAPIKey")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "AuthenticateAPIKey",
+	})
+
+	if a.rootKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.rootKey)) == 1 {
+		return auth.Principal{
+			Subject: rootSubject,
+			Method:  auth.MethodAPIKey,
+			Scopes:  []string{models.ScopeAdmin},
+		}, nil
+	}
+
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return auth.Principal{}, models.ErrInvalidAPIKey
+	}
+
+	stored, err := a.keyRepo.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return auth.Principal{}, models.ErrInvalidAPIKey
+		}
+		log.WithError(err).Error("get api key")
+		return auth.Principal{}, fmt.Errorf("get api key: %w", err)
+	}
+
+	now := a.now()
+	if !stored.IsActive(now) {
+		return auth.Principal{}, models.ErrInvalidAPIKey
+	}
+
+	if err = a.keyRepo.TouchAPIKey(ctx, stored.ID, now); err != nil {
+		// last usage is informational, do not reject the caller
+		log.WithError(err).Warn("touch api key")
+	}
+
+	return auth.Principal{
+		Subject: fmt.Sprintf("%s:%d", auth.MethodAPIKey, stored.ID),
+		Method:  auth.MethodAPIKey,
+		Scopes:  stored.Scopes,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/auth"
+)
+
+type keyRepoMock struct {
+	keys    map[string]models.APIKey
+	touched []int64
+	err     error
+}
+
+func newKeyRepoMock() *keyRepoMock {
+	return &keyRepoMock{keys: map[string]models.APIKey{}}
+}
+
+func (r *keyRepoMock) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	if r.err != nil {
+		return models.APIKey{}, r.err
+	}
+	key.ID = int64(len(r.keys) + 1)
+	r.keys[hash] = key
+	return key, nil
+}
+
+func (r *keyRepoMock) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	if r.err != nil {
+		return models.APIKey{}, r.err
+	}
+	key, ok := r.keys[hash]
+	if !ok {
+		return models.APIKey{}, models.ErrNotFound
+	}
+	return key, nil
+}
+
+func (r *keyRepoMock) GetAPIKeys(ctx context.Context) (models.APIKeys, error) {
+	return nil, r.err
+}
+
+func (r *keyRepoMock) RevokeAPIKey(ctx context.Context, id int64, at time.Time) error {
+	for hash, key := range r.keys {
+		if key.ID == id {
+			key.RevokedAt = &at
+			r.keys[hash] = key
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+func (r *keyRepoMock) TouchAPIKey(ctx context.Context, id int64, at time.Time) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+func TestIssueAndAuthenticateAPIKey(t *testing.T) {
+	repo := newKeyRepoMock()
+	uc := NewAPIKeysUsecase(repo, "")
+
+	key, plain, err := uc.IssueAPIKey(context.Background(), models.NewAPIKey{
+		Name:   "batch",
+		Scopes: models.Scopes{models.ScopeEmployeesRead},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plain) <= len(key.Prefix) || plain[:len(key.Prefix)] != key.Prefix {
+		t.Errorf("key %v does not start with prefix %v", plain, key.Prefix)
+	}
+
+	if _, ok := repo.keys[plain]; ok {
+		t.Errorf("plain key must not be stored")
+	}
+
+	p, err := uc.AuthenticateAPIKey(context.Background(), plain)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !p.HasScope(models.ScopeEmployeesRead) {
+		t.Errorf("expected scope %v, got: %v", models.ScopeEmployeesRead, p.Scopes)
+	}
+
+	if len(repo.touched) != 1 || repo.touched[0] != key.ID {
+		t.Errorf("expected last usage of key %v to be recorded, got: %v", key.ID, repo.touched)
+	}
+
+	if err = uc.RevokeAPIKey(context.Background(), key.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = uc.AuthenticateAPIKey(context.Background(), plain); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected error: %v, got: %v", auth.ErrInvalidCredentials, err)
+	}
+}
+
+func TestAuthenticateAPIKey_Expired(t *testing.T) {
+	repo := newKeyRepoMock()
+	uc := NewAPIKeysUsecase(repo, "").(*apiKeysUsecase)
+
+	expires := time.Now().Add(time.Hour)
+	_, plain, err := uc.IssueAPIKey(context.Background(), models.NewAPIKey{Name: "batch", ExpiresAt: &expires})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	uc.now = func() time.Time { return expires.Add(time.Second) }
+
+	if _, err = uc.AuthenticateAPIKey(context.Background(), plain); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected error: %v, got: %v", auth.ErrInvalidCredentials, err)
+	}
+}
+
+func TestAuthenticateAPIKey_Root(t *testing.T) {
+	uc := NewAPIKeysUsecase(newKeyRepoMock(), "root-secret")
+
+	p, err := uc.AuthenticateAPIKey(context.Background(), "root-secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !p.HasScope(models.ScopeAdmin) {
+		t.Errorf("expected scope %v, got: %v", models.ScopeAdmin, p.Scopes)
+	}
+
+	if _, err = uc.AuthenticateAPIKey(context.Background(), "sk_unknown"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected error: %v, got: %v", auth.ErrInvalidCredentials, err)
+	}
+}
+
+func TestAuthenticateAPIKey_RepoFail(t *testing.T) {
+	repo := newKeyRepoMock()
+	repo.err = fmt.Errorf("error")
+	uc := NewAPIKeysUsecase(repo, "")
+
+	_, err := uc.AuthenticateAPIKey(context.Background(), "sk_key")
+	if err == nil || errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected internal error, got: %v", err)
+	}
+}
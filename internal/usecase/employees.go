@@ -7,6 +7,7 @@ import (
 	"github.com/moguchev/service/internal/employees"
 	"github.com/moguchev/service/internal/models"
 	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,6 +21,9 @@ func NewEmployeesUsecase(eRepo employees.Repository) employees.Usecase {
 }
 
 func (e *employeesUsecase) GetEmployees(ctx context.Context, f models.EmployeeFilter) (uint, models.Employees, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.GetEmployees")
+	defer span.End()
+
 	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
 		"actor":  "usecase",
 		"func":   "GetEmployees",
@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/internal/users"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/password"
+	"github.com/moguchev/service/pkg/token"
+	"github.com/moguchev/service/pkg/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tokenType          = "Bearer"
+	refreshTokenLen    = 32
+	minPasswordLen     = 8
+	maxCredentialsLen  = 256
+	refreshTokenPrefix = "rt_"
+
+	// dummyPassword - password of hash verified for unknown logins
+	dummyPassword = "dummy password of unknown user"
+)
+
+// defaultUserScopes - scopes granted to self registered users
+var defaultUserScopes = models.Scopes{models.ScopeEmployeesRead}
+
+type usersUsecase struct {
+	userRepo users.Repository
+	tx       users.Transactor
+	tokens   *token.Manager
+	hasher   *password.Hasher
+	now      func() time.Time
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+// NewUsersUsecase will create new an usersUsecase object representation of users.Usecase interface
+func NewUsersUsecase(uRepo users.Repository, tx users.Transactor, tokens *token.Manager, hasher *password.Hasher) users.Usecase {
+	return &usersUsecase{userRepo: uRepo, tx: tx, tokens: tokens, hasher: hasher, now: time.Now}
+}
+
+// verifyDummy verifies password against hash of dummy password, so that login
+// of unknown user takes as long as login with wrong password
+func (u *usersUsecase) verifyDummy(pass string) {
+	u.dummyOnce.Do(func() {
+		u.dummyHash, _ = u.hasher.Hash(dummyPassword)
+	})
+	_ = u.hasher.Verify(u.dummyHash, pass)
+}
+
+func hashRefreshToken(t string) string {
+	sum := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateCredentials(c models.Credentials) error {
+	if c.Login == "" || len(c.Login) > maxCredentialsLen {
+		return fmt.Errorf("%w: login must be from 1 to %d characters", models.ErrInvalidArgument, maxCredentialsLen)
+	}
+	if len(c.Password) < minPasswordLen || len(c.Password) > maxCredentialsLen {
+		return fmt.Errorf("%w: password must be from %d to %d characters",
+			models.ErrInvalidArgument, minPasswordLen, maxCredentialsLen)
+	}
+	return nil
+}
+
+func (u *usersUsecase) Register(ctx context.Context, c models.Credentials) (models.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.Register")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "Register",
+		"login": c.Login,
+	})
+
+	if err := validateCredentials(c); err != nil {
+		return models.User{}, err
+	}
+
+	hash, err := u.hasher.Hash(c.Password)
+	if err != nil {
+		log.WithError(err).Error("hash password")
+		return models.User{}, fmt.Errorf("hash password: %w", err)
+	}
+
+	user, err := u.userRepo.CreateUser(ctx, models.User{
+		Login:        c.Login,
+		PasswordHash: hash,
+		Scopes:       defaultUserScopes,
+	})
+	if err != nil {
+		if !errors.Is(err, models.ErrAlreadyExists) {
+			log.WithError(err).Error("create user")
+		}
+		return models.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	log.WithField("id", user.ID).Info("user registered")
+
+	return user, nil
+}
+
+func (u *usersUsecase) Login(ctx context.Context, c models.Credentials) (models.TokenPair, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.Login")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "Login",
+		"login": c.Login,
+	})
+
+	user, err := u.userRepo.GetUserByLogin(ctx, c.Login)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			u.verifyDummy(c.Password)
+			return models.TokenPair{}, models.ErrInvalidCredentials
+		}
+		log.WithError(err).Error("get user")
+		return models.TokenPair{}, fmt.Errorf("get user: %w", err)
+	}
+
+	if err = u.hasher.Verify(user.PasswordHash, c.Password); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
+			return models.TokenPair{}, models.ErrInvalidCredentials
+		}
+		log.WithError(err).Error("verify password")
+		return models.TokenPair{}, fmt.Errorf("verify password: %w", err)
+	}
+
+	pair, err := u.issueTokens(ctx, user)
+	if err != nil {
+		log.WithError(err).Error("issue tokens")
+		return models.TokenPair{}, err
+	}
+
+	return pair, nil
+}
+
+func (u *usersUsecase) issueTokens(ctx context.Context, user models.User) (models.TokenPair, error) {
+	access, expires, err := u.tokens.Issue(strconv.FormatInt(user.ID, 10), user.Scopes)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("issue access token: %w", err)
+	}
+
+	b := make([]byte, refreshTokenLen)
+	if _, err = rand.Read(b); err != nil {
+		return models.TokenPair{}, fmt.Errorf("generate refresh token: %w", err)
+	}
+	refresh := refreshTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	_, err = u.userRepo.CreateRefreshToken(ctx, models.RefreshToken{
+		UserID:    user.ID,
+		ExpiresAt: u.now().Add(u.tokens.RefreshTTL()),
+	}, hashRefreshToken(refresh))
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("create refresh token: %w", err)
+	}
+
+	return models.TokenPair{
+		AccessToken:  access,
+		TokenType:    tokenType,
+		ExpiresAt:    expires,
+		RefreshToken: refresh,
+	}, nil
+}
+
+// Refresh rotates refresh token: the presented one is revoked and a new pair is issued.
+// Presenting an already revoked token means it was stolen, so all user tokens are revoked.
+func (u *usersUsecase) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	ctx, span := tracing.StartSpan(ctx, "usecase.Refresh")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "Refresh",
+	})
+
+	stored, err := u.userRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return models.TokenPair{}, models.ErrInvalidRefreshToken
+		}
+		log.WithError(err).Error("get refresh token")
+		return models.TokenPair{}, fmt.Errorf("get refresh token: %w", err)
+	}
+
+	log = log.WithField("user_id", stored.UserID)
+
+	now := u.now()
+	if !now.Before(stored.ExpiresAt) {
+		return models.TokenPair{}, models.ErrInvalidRefreshToken
+	}
+
+	// old token is revoked only if the new one is issued
+	var (
+		pair   models.TokenPair
+		reused bool
+	)
+	err = u.tx.Do(ctx, func(ctx context.Context) error {
+		// fn is retried on serialization failures, result of failed attempt is dropped
+		reused = false
+
+		err := u.userRepo.RevokeRefreshToken(ctx, stored.ID, now)
+		if errors.Is(err, models.ErrNotFound) {
+			// revocation of all tokens must be committed, so it is not an error of transaction
+			reused = true
+			log.Warn("revoked refresh token reused, revoking all user tokens")
+			if err = u.userRepo.RevokeUserRefreshTokens(ctx, stored.UserID, now); err != nil {
+				log.WithError(err).Error("revoke user refresh tokens")
+			}
+			return nil
+		}
+		if err != nil {
+			log.WithError(err).Error("revoke refresh token")
+			return fmt.Errorf("revoke refresh token: %w", err)
+		}
+
+		user, err := u.userRepo.GetUserByID(ctx, stored.UserID)
+		if err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				return models.ErrInvalidRefreshToken
+			}
+			log.WithError(err).Error("get user")
+			return fmt.Errorf("get user: %w", err)
+		}
+
+		if pair, err = u.issueTokens(ctx, user); err != nil {
+			log.WithError(err).Error("issue tokens")
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if reused {
+		return models.TokenPair{}, models.ErrInvalidRefreshToken
+	}
+
+	return pair, nil
+}
+
+// Revoke revokes refresh token, issued access tokens stay valid until they expire
+func (u *usersUsecase) Revoke(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.StartSpan(ctx, "usecase.Revoke")
+	defer span.End()
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "usecase",
+		"func":  "Revoke",
+	})
+
+	stored, err := u.userRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return models.ErrInvalidRefreshToken
+		}
+		log.WithError(err).Error("get refresh token")
+		return fmt.Errorf("get refresh token: %w", err)
+	}
+
+	err = u.userRepo.RevokeRefreshToken(ctx, stored.ID, u.now())
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		log.WithError(err).Error("revoke refresh token")
+		return fmt.Errorf("revoke refresh token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/moguchev/service/pkg/password"
+	"github.com/moguchev/service/pkg/token"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type userRepoMock struct {
+	users  map[string]models.User
+	tokens map[string]models.RefreshToken
+}
+
+func newUserRepoMock() *userRepoMock {
+	return &userRepoMock{users: map[string]models.User{}, tokens: map[string]models.RefreshToken{}}
+}
+
+func (r *userRepoMock) CreateUser(ctx context.Context, u models.User) (models.User, error) {
+	if _, ok := r.users[u.Login]; ok {
+		return models.User{}, models.ErrAlreadyExists
+	}
+	u.ID = int64(len(r.users) + 1)
+	r.users[u.Login] = u
+	return u, nil
+}
+
+func (r *userRepoMock) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	u, ok := r.users[login]
+	if !ok {
+		return models.User{}, models.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *userRepoMock) GetUserByID(ctx context.Context, id int64) (models.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return models.User{}, models.ErrNotFound
+}
+
+func (r *userRepoMock) CreateRefreshToken(ctx context.Context, t models.RefreshToken, hash string) (models.RefreshToken, error) {
+	t.ID = int64(len(r.tokens) + 1)
+	r.tokens[hash] = t
+	return t, nil
+}
+
+func (r *userRepoMock) GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	t, ok := r.tokens[hash]
+	if !ok {
+		return models.RefreshToken{}, models.ErrNotFound
+	}
+	return t, nil
+}
+
+func (r *userRepoMock) RevokeRefreshToken(ctx context.Context, id int64, at time.Time) error {
+	for hash, t := range r.tokens {
+		if t.ID == id && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			r.tokens[hash] = t
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+func (r *userRepoMock) RevokeUserRefreshTokens(ctx context.Context, userID int64, at time.Time) error {
+	for hash, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			r.tokens[hash] = t
+		}
+	}
+	return nil
+}
+
+func (r *userRepoMock) active() int {
+	n := 0
+	for _, t := range r.tokens {
+		if t.RevokedAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// txMock runs fn without transaction and counts calls,
+// fn is run retries more times as after serialization failure
+type txMock struct {
+	calls   int
+	retries int
+}
+
+func (m *txMock) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	for i := 0; i < m.retries; i++ {
+		_ = fn(ctx)
+	}
+	return fn(ctx)
+}
+
+// racyRepoMock reports refresh token as already revoked on the first attempt,
+// as concurrent transaction did before it was rolled back
+type racyRepoMock struct {
+	*userRepoMock
+	raced bool
+}
+
+func (r *racyRepoMock) RevokeRefreshToken(ctx context.Context, id int64, at time.Time) error {
+	if !r.raced {
+		r.raced = true
+		return models.ErrNotFound
+	}
+	return r.userRepoMock.RevokeRefreshToken(ctx, id, at)
+}
+
+// RevokeUserRefreshTokens is called only by the failed attempt, so it is rolled back
+func (r *racyRepoMock) RevokeUserRefreshTokens(ctx context.Context, userID int64, at time.Time) error {
+	return nil
+}
+
+func newTestUsersUsecase(t *testing.T, repo *userRepoMock) (*usersUsecase, *token.Manager) {
+	tokens, err := token.Config{Secret: "secret"}.CreateManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher, err := password.Config{BcryptCost: bcrypt.MinCost}.CreateHasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUsersUsecase(repo, &txMock{}, tokens, hasher).(*usersUsecase), tokens
+}
+
+func TestRegisterLogin(t *testing.T) {
+	repo := newUserRepoMock()
+	uc, tokens := newTestUsersUsecase(t, repo)
+	creds := models.Credentials{Login: "leo", Password: "password"}
+
+	user, err := uc.Register(context.Background(), creds)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if user.PasswordHash == creds.Password {
+		t.Errorf("password must be hashed")
+	}
+
+	if _, err = uc.Register(context.Background(), creds); !errors.Is(err, models.ErrAlreadyExists) {
+		t.Errorf("expected error: %v, got: %v", models.ErrAlreadyExists, err)
+	}
+
+	pair, err := uc.Login(context.Background(), creds)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := tokens.AuthenticateToken(context.Background(), pair.AccessToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Subject != "1" || !p.HasScope(models.ScopeEmployeesRead) {
+		t.Errorf("unexpected principal: %v", p)
+	}
+
+	creds.Password = "wrong password"
+	if _, err = uc.Login(context.Background(), creds); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected error: %v, got: %v", auth.ErrInvalidCredentials, err)
+	}
+
+	creds.Login = "unknown"
+	if _, err = uc.Login(context.Background(), creds); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected error: %v, got: %v", auth.ErrInvalidCredentials, err)
+	}
+
+	// unknown login costs password verification too
+	if uc.dummyHash == "" {
+		t.Errorf("dummy hash must be verified for unknown login")
+	}
+}
+
+func TestRegister_InvalidArgument(t *testing.T) {
+	uc, _ := newTestUsersUsecase(t, newUserRepoMock())
+
+	for _, c := range []models.Credentials{{Login: "", Password: "password"}, {Login: "leo", Password: "short"}} {
+		if _, err := uc.Register(context.Background(), c); !errors.Is(err, models.ErrInvalidArgument) {
+			t.Errorf("expected error: %v, got: %v", models.ErrInvalidArgument, err)
+		}
+	}
+}
+
+func TestRefresh_Rotation(t *testing.T) {
+	repo := newUserRepoMock()
+	uc, _ := newTestUsersUsecase(t, repo)
+	creds := models.Credentials{Login: "leo", Password: "password"}
+
+	if _, err := uc.Register(context.Background(), creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, err := uc.Login(context.Background(), creds)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := uc.Refresh(context.Background(), first.RefreshToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if second.RefreshToken == first.RefreshToken {
+		t.Errorf("refresh token must be rotated")
+	}
+
+	if repo.active() != 1 {
+		t.Errorf("expected exactly one active refresh token, got: %v", repo.active())
+	}
+
+	if tx := uc.tx.(*txMock); tx.calls != 1 {
+		t.Errorf("expected rotation in one transaction, got: %d", tx.calls)
+	}
+
+	// reuse of rotated token revokes the whole family
+	if _, err = uc.Refresh(context.Background(), first.RefreshToken); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Errorf("expected error: %v, got: %v", models.ErrInvalidRefreshToken, err)
+	}
+
+	if repo.active() != 0 {
+		t.Errorf("expected all refresh tokens to be revoked, got active: %v", repo.active())
+	}
+
+	if _, err = uc.Refresh(context.Background(), second.RefreshToken); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Errorf("expected error: %v, got: %v", models.ErrInvalidRefreshToken, err)
+	}
+}
+
+func TestRefresh_Retry(t *testing.T) {
+	repo := newUserRepoMock()
+	uc, _ := newTestUsersUsecase(t, repo)
+	creds := models.Credentials{Login: "leo", Password: "password"}
+
+	if _, err := uc.Register(context.Background(), creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pair, err := uc.Login(context.Background(), creds)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// reuse seen by failed attempt is not reported after successful retry
+	uc.userRepo = &racyRepoMock{userRepoMock: repo}
+	uc.tx = &txMock{retries: 1}
+
+	if _, err = uc.Refresh(context.Background(), pair.RefreshToken); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	repo := newUserRepoMock()
+	uc, _ := newTestUsersUsecase(t, repo)
+	creds := models.Credentials{Login: "leo", Password: "password"}
+
+	if _, err := uc.Register(context.Background(), creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pair, err := uc.Login(context.Background(), creds)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = uc.Revoke(context.Background(), pair.RefreshToken); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err = uc.Refresh(context.Background(), pair.RefreshToken); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Errorf("expected error: %v, got: %v", models.ErrInvalidRefreshToken, err)
+	}
+
+	if err = uc.Revoke(context.Background(), "unknown"); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Errorf("expected error: %v, got: %v", models.ErrInvalidRefreshToken, err)
+	}
+}
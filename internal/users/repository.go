@@ -0,0 +1,25 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/moguchev/service/internal/models"
+)
+
+// Repository - database level
+type Repository interface {
+	CreateUser(ctx context.Context, u models.User) (models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
+	GetUserByID(ctx context.Context, id int64) (models.User, error)
+
+	CreateRefreshToken(ctx context.Context, t models.RefreshToken, hash string) (models.RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id int64, at time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64, at time.Time) error
+}
+
+// Transactor - runs fn atomically, repository calls with ctx passed to fn are in one transaction
+type Transactor interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
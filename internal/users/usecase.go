@@ -0,0 +1,15 @@
+package users
+
+import (
+	"context"
+
+	"github.com/moguchev/service/internal/models"
+)
+
+// Usecase - business logic
+type Usecase interface {
+	Register(ctx context.Context, c models.Credentials) (models.User, error)
+	Login(ctx context.Context, c models.Credentials) (models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+	Revoke(ctx context.Context, refreshToken string) error
+}
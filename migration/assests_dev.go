@@ -1,7 +0,0 @@
-// +build dev
-
-package migration
-
-import "net/http"
-
-var Assets http.FileSystem = http.Dir("./scripts")
@@ -1,3 +0,0 @@
-package migration
-
-//go:generate vfsgendev -source="github.com/moguchev/service/migration".Assets
@@ -0,0 +1,18 @@
+package migration
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed scripts/*.psql
+var scripts embed.FS
+
+// Assets - migrations of the service schema embedded into binary
+var Assets = func() fs.FS {
+	sub, err := fs.Sub(scripts, "scripts")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}()
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/pkg/buildinfo"
+)
+
+// NewRouter returns router for internal listener with pprof, expvar and build info.
+// It must never be served on public address.
+func NewRouter() *mux.Router {
+	router := mux.NewRouter()
+
+	router.HandleFunc("/version", buildinfo.Handler).Methods(http.MethodGet)
+	router.Handle("/debug/vars", expvar.Handler()).Methods(http.MethodGet)
+
+	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	// index serves named profiles as well: heap, goroutine, allocs, block, mutex...
+	router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+
+	return router
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// MethodAPIKey - principal authenticated with X-API-Key header
+	MethodAPIKey = "api_key"
+	// MethodJWT - principal authenticated with bearer access token
+	MethodJWT = "jwt"
+)
+
+// ErrInvalidCredentials - credentials are unknown, expired or revoked
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal - authenticated caller
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+}
+
+// HasScope reports whether principal is granted the scope
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxprincipal struct{}
+
+// WithPrincipal put principal to context
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxprincipal{}, p)
+}
+
+// GetPrincipal get principal from context
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxprincipal{}).(Principal)
+	return p, ok
+}
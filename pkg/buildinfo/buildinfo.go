@@ -0,0 +1,47 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"runtime"
+)
+
+// Set at build time:
+//
+//	go build -ldflags "-X github.com/moguchev/service/pkg/buildinfo.Version=v1.0.0 \
+//	  -X github.com/moguchev/service/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/moguchev/service/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Info - build information of running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns build information
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}
+
+func init() {
+	expvar.Publish("build", expvar.Func(func() interface{} { return Get() }))
+}
+
+// Handler - answers with build information
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(Get())
+}
@@ -0,0 +1,178 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moguchev/service/pkg/utils"
+)
+
+const (
+	// DefaultTimeout - time given to each readiness check
+	DefaultTimeout = 2 * time.Second
+
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// StatusDegraded - non-critical check failed, instance still serves traffic
+	StatusDegraded = "degraded"
+)
+
+// ErrShuttingDown - readiness error after shutdown is started
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check reports dependency problem by returning error, it must respect ctx deadline
+type Check func(ctx context.Context) error
+
+// CheckResult - result of single check
+type CheckResult struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report - readiness response body
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	degrades bool
+}
+
+// Checker serves liveness and readiness probes
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown int32
+}
+
+// New creates Checker, zero timeout means DefaultTimeout
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers readiness check
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// AddDegraded registers non-critical check, its failure is reported as degraded
+// and readiness does not fail, e.g. dependency with fallback
+func (c *Checker) AddDegraded(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check, degrades: true})
+}
+
+// Shutdown makes readiness fail, so no new traffic is routed while draining
+func (c *Checker) Shutdown() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+}
+
+func (c *Checker) isShuttingDown() bool {
+	return atomic.LoadInt32(&c.shuttingDown) == 1
+}
+
+// Run runs all checks concurrently, each one with its own timeout
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make([]namedCheck, len(c.checks))
+	copy(checks, c.checks)
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks)+1)}
+
+	if c.isShuttingDown() {
+		report.Status = StatusFail
+		report.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: ErrShuttingDown.Error()}
+	}
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.run(ctx, checks[i].check)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, nc := range checks {
+		switch {
+		case results[i].Status == StatusOK:
+		case nc.degrades:
+			results[i].Status = StatusDegraded
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		default:
+			report.Status = StatusFail
+		}
+		report.Checks[nc.name] = results[i]
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// check may ignore ctx, so its result is not waited after timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := CheckResult{
+		Status:     StatusOK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// LiveHandler - process is alive while it is able to answer
+func (c *Checker) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, r, http.StatusOK, Report{Status: StatusOK})
+}
+
+// ReadyHandler - answers 503 if any critical check fails or shutdown is started
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	code := http.StatusOK
+	if report.Status == StatusFail {
+		code = http.StatusServiceUnavailable
+	}
+
+	utils.RespondWithJSON(w, r, code, report)
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ready(t *testing.T, c *Checker) (int, Report) {
+	res := httptest.NewRecorder()
+	c.ReadyHandler(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	report := Report{}
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return res.Code, report
+}
+
+func TestReadyHandler(t *testing.T) {
+	c := New(50 * time.Millisecond)
+	c.Add("db", func(ctx context.Context) error { return nil })
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["db"].Status)
+
+	c.Add("migrations", func(ctx context.Context) error { return errors.New("version 2, expected 3") })
+	c.Add("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	code, report = ready(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["db"].Status)
+	assert.Equal(t, "version 2, expected 3", report.Checks["migrations"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestReadyHandler_Degraded(t *testing.T) {
+	c := New(0)
+	c.Add("db", func(ctx context.Context) error { return nil })
+	c.AddDegraded("db_breaker", func(ctx context.Context) error { return errors.New("circuit is open") })
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusDegraded, report.Checks["db_breaker"].Status)
+	assert.Equal(t, "circuit is open", report.Checks["db_breaker"].Error)
+
+	// critical failure wins
+	c.Add("migrations", func(ctx context.Context) error { return errors.New("dirty") })
+
+	code, report = ready(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, report.Status)
+}
+
+func TestReadyHandler_Shutdown(t *testing.T) {
+	c := New(0)
+	c.Shutdown()
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, ErrShuttingDown.Error(), report.Checks["shutdown"].Error)
+
+	res := httptest.NewRecorder()
+	c.LiveHandler(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+}
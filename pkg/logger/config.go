@@ -1,8 +1,8 @@
 package logger
 
 import (
-	"io/ioutil"
-	"os"
+	"errors"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 )
@@ -39,43 +39,84 @@ var levelMap = map[string]logrus.Level{
 
 // Config is a configuration for logger
 type Config struct {
-	Output    string `yaml:"output" json:"output" toml:"output"`          // enum (stdout|stderr|vacuum|path/to/file)
+	Output    string `yaml:"output" json:"output" toml:"output"`          // enum (stdout|stderr|vacuum|syslog|path/to/file)
 	Formatter string `yaml:"formatter" json:"formatter" toml:"formatter"` // enum (json|text)
 	Level     string `yaml:"level" json:"level" toml:"level"`             // enum (panic|fatal|error|warning|info|debug|trace)
+	// Levels - levels of actors overriding Level, e.g. {repository: debug}
+	Levels map[string]string `yaml:"levels" json:"levels" toml:"levels"`
+	// Rotation of Output file
+	Rotation *RotationConfig `yaml:"rotation" json:"rotation" toml:"rotation"`
+	// Outputs replace Output, Formatter and Rotation to write to several sinks at once
+	Outputs []OutputConfig `yaml:"outputs" json:"outputs" toml:"outputs"`
+	// Redact extends default redaction of sensitive values
+	Redact *RedactConfig `yaml:"redact" json:"redact" toml:"redact"`
 }
 
-// CreateLogger returns logger according config
-func (config Config) CreateLogger() *logrus.Logger {
-	logger := logrus.New()
+// Validate checks levels, formatters and outputs without opening them
+func (config Config) Validate() error {
+	if _, err := ParseLevels(config.Level, config.Levels); err != nil {
+		return err
+	}
 
-	switch config.Output {
-	case Stdout, "":
-		logger.SetOutput(os.Stdout)
-	case Stderr:
-		logger.SetOutput(os.Stderr)
-	case Vacuum:
-		logger.SetOutput(ioutil.Discard)
-	default:
-		f, err := os.OpenFile(config.Output, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-		if err != nil {
-			logger.SetOutput(os.Stdout)
-			logger.WithError(err).Debug("falling to stdout")
-		} else {
-			logger.SetOutput(f)
+	for _, o := range config.outputs() {
+		if _, ok := formatters[o.Formatter]; !ok && o.Formatter != "" {
+			return fmt.Errorf("unknown formatter %q", o.Formatter)
+		}
+		if o.Output == Syslog && o.Syslog != nil && (o.Syslog.Network == "") != (o.Syslog.Address == "") {
+			return errors.New("syslog network and address must be set together")
+		}
+		if r := o.Rotation; r != nil && (r.MaxSizeMB < 0 || r.Every < 0 || r.MaxAge < 0 || r.MaxBackups < 0) {
+			return fmt.Errorf("rotation of %q: values must not be negative", o.Output)
 		}
 	}
 
-	formatter, ok := formatters[config.Formatter]
-	if !ok {
-		formatter = &logrus.TextFormatter{}
+	if config.Redact != nil {
+		if _, err := NewRedactor(*config.Redact); err != nil {
+			return err
+		}
 	}
-	logger.SetFormatter(formatter)
 
-	lvl, ok := levelMap[config.Level]
-	if !ok {
-		lvl = logrus.DebugLevel
+	return nil
+}
+
+// NewLogger returns logger according config
+func (config Config) NewLogger() (*logrus.Logger, error) {
+	logger := logrus.New()
+
+	levels, err := ParseLevels(config.Level, config.Levels)
+	if err != nil {
+		return nil, err
 	}
-	logger.SetLevel(lvl)
 
+	formatter, err := config.setOutputs(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Redact == nil || !config.Redact.Disabled {
+		rcfg := RedactConfig{}
+		if config.Redact != nil {
+			rcfg = *config.Redact
+		}
+		redactor, err := NewRedactor(rcfg)
+		if err != nil {
+			return nil, err
+		}
+		formatter = redactor.Formatter(formatter)
+	}
+
+	logger.SetFormatter(newLevelFormatter(formatter, levels))
+	SetLevels(logger, levels)
+
+	return logger, nil
+}
+
+// CreateLogger returns logger according config, falling to debug text logger to stdout on error
+func (config Config) CreateLogger() *logrus.Logger {
+	logger, err := config.NewLogger()
+	if err != nil {
+		logger = Config{}.CreateLogger()
+		logger.WithError(err).Warn("falling to stdout")
+	}
 	return logger
 }
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+type levelMessage struct {
+	Level  string            `json:"level,omitempty"`
+	Actors map[string]string `json:"actors,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// ParseLevel parses level name used in config
+func ParseLevel(name string) (logrus.Level, bool) {
+	lvl, ok := levelMap[name]
+	return lvl, ok
+}
+
+// LevelName returns level name used in config
+func LevelName(lvl logrus.Level) string {
+	for name, l := range levelMap {
+		if l == lvl {
+			return name
+		}
+	}
+	return lvl.String()
+}
+
+func respondLevel(w http.ResponseWriter, code int, msg levelMessage) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(msg)
+}
+
+// LevelHandler - GET returns current levels,
+// PUT {"level": "info", "actors": {"repository": "debug"}} replaces them
+func LevelHandler(l *logrus.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			def, actors := FormatLevels(GetLevels(l))
+			respondLevel(w, http.StatusOK, levelMessage{Level: def, Actors: actors})
+			return
+		}
+
+		msg := levelMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			respondLevel(w, http.StatusBadRequest, levelMessage{Error: err.Error()})
+			return
+		}
+
+		if msg.Level == "" {
+			respondLevel(w, http.StatusBadRequest, levelMessage{Error: "level is required"})
+			return
+		}
+
+		levels, err := ParseLevels(msg.Level, msg.Actors)
+		if err != nil {
+			respondLevel(w, http.StatusBadRequest, levelMessage{Error: err.Error()})
+			return
+		}
+
+		prev, _ := FormatLevels(GetLevels(l))
+		SetLevels(l, levels)
+		l.WithFields(logrus.Fields{
+			"from":   prev,
+			"to":     msg.Level,
+			"actors": msg.Actors,
+		}).Warn("log level changed")
+
+		def, actors := FormatLevels(levels)
+		respondLevel(w, http.StatusOK, levelMessage{Level: def, Actors: actors})
+	}
+}
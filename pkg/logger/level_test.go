@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelHandler(t *testing.T) {
+	l := Config{Output: Vacuum, Level: "info"}.CreateLogger()
+
+	h := LevelHandler(l)
+
+	res := httptest.NewRecorder()
+	h(res, httptest.NewRequest(http.MethodGet, "/log-level", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"level":"info"}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	h(res, httptest.NewRequest(http.MethodPut, "/log-level",
+		strings.NewReader(`{"level":"warning","actors":{"repository":"debug"}}`)))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, Levels{
+		Default: logrus.WarnLevel,
+		Actors:  map[string]logrus.Level{"repository": logrus.DebugLevel},
+	}, GetLevels(l))
+
+	res = httptest.NewRecorder()
+	h(res, httptest.NewRequest(http.MethodPut, "/log-level", strings.NewReader(`{"level":"verbose"}`)))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, logrus.WarnLevel, GetLevels(l).Default)
+}
+
+func TestLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	l := Config{Output: Vacuum, Level: "info", Levels: map[string]string{"repository": "debug"}}.CreateLogger()
+	l.SetOutput(buf)
+
+	l.WithField(ActorField, "repository").Debug("query")
+	l.WithField(ActorField, "usecase").Debug("skipped")
+	l.Debug("skipped")
+	l.Info("info")
+
+	assert.Contains(t, buf.String(), "query")
+	assert.Contains(t, buf.String(), "info")
+	assert.NotContains(t, buf.String(), "skipped")
+
+	buf.Reset()
+
+	if err := (Config{Level: "error"}).ApplyLevels(l); err != nil {
+		t.Fatal(err)
+	}
+
+	l.WithField(ActorField, "repository").Debug("skipped")
+	l.Error("error")
+
+	assert.NotContains(t, buf.String(), "skipped")
+	assert.Contains(t, buf.String(), "error")
+	assert.Equal(t, logrus.ErrorLevel, l.GetLevel())
+
+	assert.Error(t, Config{Level: "verbose"}.ApplyLevels(l))
+}
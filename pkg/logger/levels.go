@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ActorField - field with component name: repository, usecase, handler...
+const ActorField = "actor"
+
+// Levels - default level and levels overridden for actors
+type Levels struct {
+	Default logrus.Level
+	Actors  map[string]logrus.Level
+}
+
+// lowest returns most verbose of levels, logger must let such entries through
+func (l Levels) lowest() logrus.Level {
+	lvl := l.Default
+	for _, a := range l.Actors {
+		if a > lvl {
+			lvl = a
+		}
+	}
+	return lvl
+}
+
+func (l Levels) enabled(e *logrus.Entry) bool {
+	max := l.Default
+	if actor, ok := e.Data[ActorField].(string); ok {
+		if a, ok := l.Actors[actor]; ok {
+			max = a
+		}
+	}
+	return e.Level <= max
+}
+
+// levelFormatter drops entries above level of their actor.
+// Hooks are still fired for dropped entries.
+type levelFormatter struct {
+	logrus.Formatter
+	levels atomic.Value // Levels
+}
+
+func (f *levelFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if !f.levels.Load().(Levels).enabled(e) {
+		return nil, nil
+	}
+	return f.Formatter.Format(e)
+}
+
+func newLevelFormatter(f logrus.Formatter, levels Levels) *levelFormatter {
+	lf := &levelFormatter{Formatter: f}
+	lf.levels.Store(levels)
+	return lf
+}
+
+// SetLevels changes levels of logger created by CreateLogger, safe to call while logging
+func SetLevels(l *logrus.Logger, levels Levels) {
+	lf, ok := l.Formatter.(*levelFormatter)
+	if !ok {
+		l.SetLevel(levels.Default)
+		return
+	}
+	lf.levels.Store(levels)
+	l.SetLevel(levels.lowest())
+}
+
+// GetLevels returns current levels of logger
+func GetLevels(l *logrus.Logger) Levels {
+	if lf, ok := l.Formatter.(*levelFormatter); ok {
+		return lf.levels.Load().(Levels)
+	}
+	return Levels{Default: l.GetLevel()}
+}
+
+// ParseLevels parses level names used in config, empty default means debug
+func ParseLevels(def string, actors map[string]string) (Levels, error) {
+	levels := Levels{Default: logrus.DebugLevel, Actors: make(map[string]logrus.Level, len(actors))}
+
+	if def != "" {
+		lvl, ok := ParseLevel(def)
+		if !ok {
+			return Levels{}, fmt.Errorf("unknown level %q", def)
+		}
+		levels.Default = lvl
+	}
+
+	for actor, name := range actors {
+		lvl, ok := ParseLevel(name)
+		if !ok {
+			return Levels{}, fmt.Errorf("unknown level %q of actor %q", name, actor)
+		}
+		levels.Actors[actor] = lvl
+	}
+
+	return levels, nil
+}
+
+// FormatLevels returns level names as in config
+func FormatLevels(levels Levels) (string, map[string]string) {
+	actors := make(map[string]string, len(levels.Actors))
+	for actor, lvl := range levels.Actors {
+		actors[actor] = LevelName(lvl)
+	}
+	return LevelName(levels.Default), actors
+}
+
+// ApplyLevels sets levels from config to logger, used on config reload
+func (config Config) ApplyLevels(l *logrus.Logger) error {
+	levels, err := ParseLevels(config.Level, config.Levels)
+	if err != nil {
+		return err
+	}
+	SetLevels(l, levels)
+	return nil
+}
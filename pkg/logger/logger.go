@@ -10,16 +10,32 @@ type ctxlog struct{}
 
 // WithLogger put logger to context
 func WithLogger(ctx context.Context, l *logrus.Logger) context.Context {
-	return context.WithValue(ctx, ctxlog{}, l)
+	return WithEntry(ctx, logrus.NewEntry(l))
+}
+
+// WithEntry put logger entry with request scoped fields to context
+func WithEntry(ctx context.Context, e *logrus.Entry) context.Context {
+	return context.WithValue(ctx, ctxlog{}, e)
+}
+
+// WithFields put logger entry extended with fields to context
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return WithEntry(ctx, GetLogger(ctx).WithFields(fields))
 }
 
 var DefaultLogger = logrus.New()
 
-// GetLogger get logger from context, or DefaultLogger if not exists
-func GetLogger(ctx context.Context) *logrus.Logger {
-	l, ok := ctx.Value(ctxlog{}).(*logrus.Logger)
+// FromContext get logger entry from context, reports whether it exists
+func FromContext(ctx context.Context) (*logrus.Entry, bool) {
+	e, ok := ctx.Value(ctxlog{}).(*logrus.Entry)
+	return e, ok
+}
+
+// GetLogger get logger entry from context, or DefaultLogger entry if not exists
+func GetLogger(ctx context.Context) *logrus.Entry {
+	e, ok := FromContext(ctx)
 	if !ok {
-		l = DefaultLogger
+		e = logrus.NewEntry(DefaultLogger)
 	}
-	return l
+	return e
 }
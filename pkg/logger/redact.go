@@ -0,0 +1,279 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces sensitive values
+const Redacted = "[REDACTED]"
+
+// maxRedactDepth - nested values deeper are left as is, it also protects from cycles
+const maxRedactDepth = 10
+
+var (
+	// DefaultRedactFields - names of fields, struct fields and map keys whose values are always hidden.
+	// Names are compared ignoring case, "_" and "-", so "api_key" also matches APIKey.
+	DefaultRedactFields = []string{
+		"password", "passwd", "secret", "token", "access_token", "refresh_token",
+		"api_key", "admin_key", "key_hash", "password_hash", "authorization", "salary",
+	}
+
+	// DefaultRedactPatterns - regexps of sensitive parts of strings, if regexp has
+	// groups only the groups are hidden, otherwise the whole match
+	DefaultRedactPatterns = []string{
+		`(?i)\bpassword\s*=\s*('[^']*'|[^\s'"]+)`, // key/value DSN
+		`://[^:/@\s]+:([^@\s]+)@`,                 // URL credentials
+		`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`,   // authorization header
+		`\b(?:sk|rt)_([A-Za-z0-9_-]{16,})`,        // api keys and refresh tokens
+	}
+)
+
+// RedactConfig - fields and patterns added to defaults
+type RedactConfig struct {
+	Disabled bool     `yaml:"disabled" json:"disabled" toml:"disabled"`
+	Fields   []string `yaml:"fields" json:"fields" toml:"fields"`
+	Patterns []string `yaml:"patterns" json:"patterns" toml:"patterns"`
+}
+
+// Redactor hides sensitive values of log entries
+type Redactor struct {
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// NewRedactor returns Redactor with defaults extended by config
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	r := &Redactor{fields: map[string]struct{}{}}
+
+	for _, f := range append(append([]string{}, DefaultRedactFields...), cfg.Fields...) {
+		r.fields[normalizeField(f)] = struct{}{}
+	}
+
+	for _, p := range append(append([]string{}, DefaultRedactPatterns...), cfg.Patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+func (r *Redactor) sensitive(name string) bool {
+	_, ok := r.fields[normalizeField(name)]
+	return ok
+}
+
+// String hides sensitive parts of s
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllString(s, Redacted)
+			continue
+		}
+
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			idx := re.FindStringSubmatchIndex(match)
+			var b strings.Builder
+			last := 0
+			for i := 2; i < len(idx); i += 2 {
+				if idx[i] < 0 {
+					continue
+				}
+				b.WriteString(match[last:idx[i]])
+				b.WriteString(Redacted)
+				last = idx[i+1]
+			}
+			b.WriteString(match[last:])
+			return b.String()
+		})
+	}
+	return s
+}
+
+// Fields returns copy of fields with sensitive values hidden, nested
+// structs, maps and slices are converted only if they contain such values
+func (r *Redactor) Fields(fields logrus.Fields) logrus.Fields {
+	out := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		if r.sensitive(k) {
+			out[k] = Redacted
+			continue
+		}
+		if nv, changed := r.value(reflect.ValueOf(v), 0); changed {
+			out[k] = nv
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// fieldName returns json name of struct field, or its name
+func fieldName(f reflect.StructField) string {
+	if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return f.Name
+}
+
+// value returns redacted copy of v and reports whether anything was hidden
+func (r *Redactor) value(v reflect.Value, depth int) (interface{}, bool) {
+	if !v.IsValid() || depth > maxRedactDepth {
+		return nil, false
+	}
+
+	if v.Type() == timeType {
+		return nil, false
+	}
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false
+	}
+
+	if v.Type().Implements(errorType) {
+		s := v.Interface().(error).Error()
+		if rs := r.String(s); rs != s {
+			return rs, true
+		}
+		return nil, false
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if s := r.String(v.String()); s != v.String() {
+			return s, true
+		}
+
+	case reflect.Ptr, reflect.Interface:
+		return r.value(v.Elem(), depth+1)
+
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		changed := false
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := fieldName(f)
+			fv := v.Field(i)
+			switch {
+			case r.sensitive(f.Name) || r.sensitive(name):
+				if !isNil(fv) {
+					out[name] = Redacted
+					changed = true
+					continue
+				}
+				out[name] = nil
+			default:
+				if nv, ok := r.value(fv, depth+1); ok {
+					out[name] = nv
+					changed = true
+					continue
+				}
+				out[name] = plain(fv)
+			}
+		}
+		if changed {
+			return out, true
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		out := make(map[string]interface{}, v.Len())
+		changed := false
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			switch nv, ok := r.value(iter.Value(), depth+1); {
+			case r.sensitive(k):
+				out[k] = Redacted
+				changed = true
+			case ok:
+				out[k] = nv
+				changed = true
+			default:
+				out[k] = iter.Value().Interface()
+			}
+		}
+		if changed {
+			return out, true
+		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, false
+		}
+		out := make([]interface{}, v.Len())
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			if nv, ok := r.value(v.Index(i), depth+1); ok {
+				out[i] = nv
+				changed = true
+				continue
+			}
+			out[i] = plain(v.Index(i))
+		}
+		if changed {
+			return out, true
+		}
+	}
+
+	return nil, false
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// plain returns value as is, pointers are dereferenced as formatters do not do it
+func plain(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// redactFormatter hides sensitive values before formatting
+type redactFormatter struct {
+	logrus.Formatter
+	redactor *Redactor
+}
+
+func (f *redactFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	ne := *e
+	ne.Data = f.redactor.Fields(e.Data)
+	ne.Message = f.redactor.String(e.Message)
+	return f.Formatter.Format(&ne)
+}
+
+// Formatter wraps formatter to hide sensitive values of entries
+func (r *Redactor) Formatter(f logrus.Formatter) logrus.Formatter {
+	return &redactFormatter{Formatter: f, redactor: r}
+}
@@ -0,0 +1,308 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Syslog - output writing to local syslog/journald socket or remote syslog
+const Syslog = "syslog"
+
+// OutputConfig is a configuration of one log sink
+type OutputConfig struct {
+	Output    string `yaml:"output" json:"output" toml:"output"`          // enum (stdout|stderr|vacuum|syslog|path/to/file)
+	Formatter string `yaml:"formatter" json:"formatter" toml:"formatter"` // enum (json|text)
+	// Rotation is used for file output, file is never rotated if not set
+	Rotation *RotationConfig `yaml:"rotation" json:"rotation" toml:"rotation"`
+	// Syslog is used for syslog output, local socket is used if not set
+	Syslog *SyslogConfig `yaml:"syslog" json:"syslog" toml:"syslog"`
+}
+
+// RotationConfig - file is rotated when it exceeds MaxSizeMB or every Every
+type RotationConfig struct {
+	MaxSizeMB int           `yaml:"max_size_mb" json:"max_size_mb" toml:"max_size_mb"`
+	Every     time.Duration `yaml:"every" json:"every" toml:"every"`
+	// MaxAge and MaxBackups limit retention of rotated files, zero means unlimited
+	MaxAge     time.Duration `yaml:"max_age" json:"max_age" toml:"max_age"`
+	MaxBackups int           `yaml:"max_backups" json:"max_backups" toml:"max_backups"`
+	Compress   bool          `yaml:"compress" json:"compress" toml:"compress"`
+}
+
+// SyslogConfig - remote syslog address, e.g. network "udp" and address "localhost:514"
+type SyslogConfig struct {
+	Network string `yaml:"network" json:"network" toml:"network"`
+	Address string `yaml:"address" json:"address" toml:"address"`
+	Tag     string `yaml:"tag" json:"tag" toml:"tag"`
+}
+
+// reopener is implemented by file outputs
+type reopener interface {
+	Reopen() error
+	Close() error
+}
+
+var files = struct {
+	sync.Mutex
+	list []reopener
+}{}
+
+func registerFile(f reopener) {
+	files.Lock()
+	defer files.Unlock()
+	files.list = append(files.list, f)
+}
+
+// Reopen reopens all log files, call it on SIGUSR1 after external logrotate moved them
+func Reopen() error {
+	files.Lock()
+	defer files.Unlock()
+
+	var first error
+	for _, f := range files.list {
+		if err := f.Reopen(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close closes all log files and stops their rotation, loggers writing to them must not be used after
+func Close() error {
+	files.Lock()
+	defer files.Unlock()
+
+	var first error
+	for _, f := range files.list {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	files.list = nil
+	return first
+}
+
+// file - append only file which can be reopened
+type file struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+func openFile(path string) (*file, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &file{path: path, f: f}, nil
+}
+
+func (f *file) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Write(b)
+}
+
+func (f *file) Reopen() error {
+	nf, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("reopen %s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	old := f.f
+	f.f = nf
+	f.mu.Unlock()
+
+	return old.Close()
+}
+
+func (f *file) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.f.Close()
+}
+
+// rotatingFile - file rotated by size with lumberjack and by time with ticker
+type rotatingFile struct {
+	*lumberjack.Logger
+	done chan struct{}
+	once sync.Once
+}
+
+func openRotatingFile(path string, cfg RotationConfig) (*rotatingFile, error) {
+	// check access right away, lumberjack opens file on first write
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+
+	rf := &rotatingFile{Logger: &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     int(math.Ceil(cfg.MaxAge.Hours() / 24)),
+		MaxBackups: cfg.MaxBackups,
+		LocalTime:  true,
+		Compress:   cfg.Compress,
+	}, done: make(chan struct{})}
+
+	if rf.MaxSize == 0 {
+		// lumberjack treats zero as 100 megabytes
+		rf.MaxSize = math.MaxInt32
+	}
+
+	if cfg.Every > 0 {
+		go rf.rotateEvery(cfg.Every)
+	}
+
+	return rf, nil
+}
+
+// rotateEvery rotates file until it is closed
+func (rf *rotatingFile) rotateEvery(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rf.done:
+			return
+		case <-ticker.C:
+			if err := rf.Rotate(); err != nil {
+				fmt.Fprintf(os.Stderr, "rotate log file %s: %v\n", rf.Filename, err)
+			}
+		}
+	}
+}
+
+// Reopen closes file, lumberjack opens it again on next write
+func (rf *rotatingFile) Reopen() error {
+	return rf.Logger.Close()
+}
+
+// Close stops rotation and closes file
+func (rf *rotatingFile) Close() error {
+	rf.once.Do(func() { close(rf.done) })
+	return rf.Logger.Close()
+}
+
+// sink - output with its own formatter
+type sink interface {
+	write(e *logrus.Entry, b []byte) error
+}
+
+type writerSink struct {
+	io.Writer
+}
+
+func (s writerSink) write(_ *logrus.Entry, b []byte) error {
+	_, err := s.Write(b)
+	return err
+}
+
+type formattedSink struct {
+	formatter logrus.Formatter
+	sink      sink
+}
+
+// sinksFormatter writes entry to every sink, logger output itself is discarded
+type sinksFormatter struct {
+	sinks []formattedSink
+}
+
+func (f *sinksFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	for _, s := range f.sinks {
+		b, err := s.formatter.Format(e)
+		if err == nil {
+			err = s.sink.write(e, b)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "write log entry: %v\n", err)
+		}
+	}
+	return nil, nil
+}
+
+func getFormatter(name string) logrus.Formatter {
+	formatter, ok := formatters[name]
+	if !ok {
+		formatter = &logrus.TextFormatter{}
+	}
+	return formatter
+}
+
+func (o OutputConfig) createSink() (sink, error) {
+	switch o.Output {
+	case Stdout, "":
+		return writerSink{os.Stdout}, nil
+	case Stderr:
+		return writerSink{os.Stderr}, nil
+	case Vacuum:
+		return writerSink{ioutil.Discard}, nil
+	case Syslog:
+		cfg := SyslogConfig{}
+		if o.Syslog != nil {
+			cfg = *o.Syslog
+		}
+		return newSyslogSink(cfg)
+	}
+
+	if o.Rotation != nil {
+		rf, err := openRotatingFile(o.Output, *o.Rotation)
+		if err != nil {
+			return nil, err
+		}
+		registerFile(rf)
+		return writerSink{rf}, nil
+	}
+
+	f, err := openFile(o.Output)
+	if err != nil {
+		return nil, err
+	}
+	registerFile(f)
+	return writerSink{f}, nil
+}
+
+// outputs returns Outputs, or single output described by Output, Formatter and Rotation
+func (config Config) outputs() []OutputConfig {
+	if len(config.Outputs) > 0 {
+		return config.Outputs
+	}
+	return []OutputConfig{{
+		Output:    config.Output,
+		Formatter: config.Formatter,
+		Rotation:  config.Rotation,
+	}}
+}
+
+// setOutputs sets output and formatter of logger, entries are dispatched by
+// sinksFormatter if there are several outputs or syslog
+func (config Config) setOutputs(logger *logrus.Logger) (logrus.Formatter, error) {
+	outputs := config.outputs()
+	sinks := make([]formattedSink, 0, len(outputs))
+
+	for _, o := range outputs {
+		s, err := o.createSink()
+		if err != nil {
+			return nil, fmt.Errorf("create %q output: %w", o.Output, err)
+		}
+		sinks = append(sinks, formattedSink{formatter: getFormatter(o.Formatter), sink: s})
+	}
+
+	if ws, ok := sinks[0].sink.(writerSink); ok && len(sinks) == 1 {
+		logger.SetOutput(ws.Writer)
+		return sinks[0].formatter, nil
+	}
+
+	logger.SetOutput(ioutil.Discard)
+	return &sinksFormatter{sinks: sinks}, nil
+}
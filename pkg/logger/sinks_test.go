@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogger_Outputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	jsonPath, textPath := filepath.Join(dir, "json.log"), filepath.Join(dir, "text.log")
+
+	l, err := Config{
+		Level: "info",
+		Outputs: []OutputConfig{
+			{Output: jsonPath, Formatter: JSONFormatter},
+			{Output: textPath, Formatter: TextFormatter, Rotation: &RotationConfig{MaxBackups: 1}},
+		},
+	}.NewLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	l.WithField("id", 42).Info("first")
+	l.Debug("skipped")
+
+	// external logrotate moves files away
+	for _, p := range []string{jsonPath, textPath} {
+		if err = os.Rename(p, p+".1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = Reopen(); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("second")
+
+	read := func(p string) string {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	assert.Contains(t, read(jsonPath+".1"), `"msg":"first"`)
+	assert.Contains(t, read(textPath+".1"), `msg=first id=42`)
+	assert.NotContains(t, read(jsonPath+".1"), "skipped")
+	assert.Contains(t, read(jsonPath), `"msg":"second"`)
+	assert.Contains(t, read(textPath), `msg=second`)
+	assert.False(t, strings.Contains(read(textPath), "first"))
+}
+
+func TestNewLogger_Error(t *testing.T) {
+	_, err := Config{Output: filepath.Join("not", "existing", "dir", "service.log")}.NewLogger()
+	assert.Error(t, err)
+
+	_, err = Config{Level: "verbose"}.NewLogger()
+	assert.Error(t, err)
+}
+
+func TestClose_StopsRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		_, err = Config{
+			Output:   filepath.Join(dir, "service.log"),
+			Rotation: &RotationConfig{Every: time.Hour},
+		}.NewLogger()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// rotation goroutines exit asynchronously
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
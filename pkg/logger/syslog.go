@@ -0,0 +1,48 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func newSyslogSink(cfg SyslogConfig) (sink, error) {
+	if cfg.Tag == "" {
+		cfg.Tag = filepath.Base(os.Args[0])
+	}
+
+	// empty network and address means local syslog socket, served by journald on systemd hosts
+	w, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("dial syslog: %w", err)
+	}
+
+	return syslogSink{w: w}, nil
+}
+
+func (s syslogSink) write(e *logrus.Entry, b []byte) error {
+	msg := string(b)
+
+	switch e.Level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return s.w.Crit(msg)
+	case logrus.ErrorLevel:
+		return s.w.Err(msg)
+	case logrus.WarnLevel:
+		return s.w.Warning(msg)
+	case logrus.InfoLevel:
+		return s.w.Info(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package logger
+
+import "errors"
+
+func newSyslogSink(cfg SyslogConfig) (sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
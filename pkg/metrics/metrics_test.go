@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/moguchev/service/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New("test")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+	handler := utils.WrapRouter(router, m.Middleware)
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/employees/42", nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/employees/42", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown/1", nil))
+
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, string(body), `test_http_requests_total{method="GET",route="/employees/{id}",status="404"} 2`)
+	assert.Contains(t, string(body), `test_http_request_duration_seconds_count{method="GET",route="/employees/{id}",status="404"} 2`)
+	assert.Contains(t, string(body), `test_http_requests_in_flight{method="GET",route="/employees/{id}"} 0`)
+	assert.Contains(t, string(body), `test_http_requests_total{method="POST",route="unmatched",status="405"} 1`)
+	assert.Contains(t, string(body), `test_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+func TestRegisterDB(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+
+	m := New("test")
+	if err = m.RegisterDB(sqlx.NewDb(mockDB, "sqlmock"), "primary"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.True(t, strings.Contains(res.Body.String(), `go_sql_open_connections{db_name="primary"}`))
+}
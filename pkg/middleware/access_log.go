@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/moguchev/service/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// AccessLogConfig - configuration of access log
+type AccessLogConfig struct {
+	// SampleRate - fraction of requests answered with status < 500 to log, 0 means all
+	SampleRate float64 `yaml:"sample_rate" json:"sample_rate" toml:"sample_rate"`
+	// ExcludePaths - paths never logged, e.g. health checks
+	ExcludePaths []string `yaml:"exclude_paths" json:"exclude_paths" toml:"exclude_paths"`
+	// TrustedProxies - IPs or CIDRs whose X-Forwarded-For / X-Real-IP headers are trusted
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// Validate checks sample rate and trusted proxies
+func (c AccessLogConfig) Validate() error {
+	_, err := newAccessLog(c)
+	return err
+}
+
+// accessLog - AccessLogConfig prepared for use
+type accessLog struct {
+	rate    float64
+	exclude map[string]struct{}
+	proxies []*net.IPNet
+}
+
+func newAccessLog(c AccessLogConfig) (*accessLog, error) {
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return nil, fmt.Errorf("sample_rate: must be in [0, 1], got %v", c.SampleRate)
+	}
+
+	a := &accessLog{
+		rate:    c.SampleRate,
+		exclude: map[string]struct{}{},
+	}
+
+	if a.rate == 0 {
+		a.rate = 1
+	}
+
+	for _, p := range c.ExcludePaths {
+		a.exclude[p] = struct{}{}
+	}
+
+	for _, p := range c.TrustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_proxies: %w", err)
+		}
+		a.proxies = append(a.proxies, n)
+	}
+
+	return a, nil
+}
+
+func (a *accessLog) trusted(ip net.IP) bool {
+	for _, n := range a.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns client address, forwarded headers are honoured only from trusted proxies
+func (a *accessLog) remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !a.trusted(ip) {
+		return host
+	}
+
+	// the rightmost address not belonging to our proxies is the client
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			hopIP := net.ParseIP(hop)
+			if hopIP == nil {
+				break
+			}
+			host = hop
+			if !a.trusted(hopIP) {
+				break
+			}
+		}
+		return host
+	}
+
+	if real := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); real != nil {
+		return real.String()
+	}
+
+	return host
+}
+
+// SetAccessLog - replaces access log configuration, safe to call while serving requests
+func (mw *Middleware) SetAccessLog(c AccessLogConfig) error {
+	set, err := mw.PrepareAccessLog(c)
+	if err != nil {
+		return err
+	}
+	set()
+	return nil
+}
+
+// PrepareAccessLog - prepares access log configuration and returns function replacing
+// current one with it, so that it is swapped together with other reloaded components
+func (mw *Middleware) PrepareAccessLog(c AccessLogConfig) (func(), error) {
+	a, err := newAccessLog(c)
+	if err != nil {
+		return nil, err
+	}
+	return func() { mw.access.Store(a) }, nil
+}
+
+// AccessLogMiddleware - logs every served request
+func (mw *Middleware) AccessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := mw.access.Load().(*accessLog)
+		if _, ok := a.exclude[r.URL.Path]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rw := utils.NewResponseWriter(w)
+
+		next.ServeHTTP(rw, r)
+
+		latency := time.Since(start)
+
+		if rw.Status() < http.StatusInternalServerError && a.rate < 1 && rand.Float64() >= a.rate { // nolint:gosec // sampling
+			return
+		}
+
+		log := mw.getLogger(r).WithFields(logrus.Fields{
+			"method":       r.Method,
+			"route":        utils.RouteTemplate(r, utils.UnmatchedRoute),
+			"path":         r.URL.Path,
+			"status":       rw.Status(),
+			"latency_ms":   float64(latency.Microseconds()) / 1000,
+			"size":         rw.Size(),
+			"remote_ip":    a.remoteIP(r),
+			"user_agent":   r.UserAgent(),
+			RequestIDField: GetRequestID(r.Context()),
+		})
+
+		if rw.Status() >= http.StatusInternalServerError {
+			log.Error("access")
+			return
+		}
+		log.Info("access")
+	})
+}
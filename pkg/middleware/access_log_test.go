@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+
+	mw := InitMiddleware(l)
+	err := mw.SetAccessLog(AccessLogConfig{ExcludePaths: []string{"/healthz"}, TrustedProxies: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(mw.AccessLogMiddleware)
+	router.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("12345"))
+	})
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/employees/42", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+	req.Header.Set("User-Agent", "test")
+
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	fields := map[string]interface{}{}
+	if err = json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("unexpected log output %q: %v", buf.String(), err)
+	}
+
+	assert.Equal(t, "/employees/{id}", fields["route"])
+	assert.Equal(t, float64(http.StatusTeapot), fields["status"])
+	assert.Equal(t, float64(5), fields["size"])
+	assert.Equal(t, "203.0.113.7", fields["remote_ip"])
+	assert.Equal(t, "test", fields["user_agent"])
+	assert.Contains(t, fields, "latency_ms")
+
+	buf.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, buf.String())
+}
+
+func TestAccessLogMiddlewareUnmatched(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+
+	mw := InitMiddleware(l)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	handler := utils.WrapRouter(router, mw.AccessLogMiddleware)
+
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		buf.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/unknown", nil))
+
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+			t.Fatalf("unexpected log output %q: %v", buf.String(), err)
+		}
+
+		assert.Equal(t, utils.UnmatchedRoute, fields["route"])
+		assert.Equal(t, "/unknown", fields["path"])
+		assert.Equal(t, float64(http.StatusNotFound), fields["status"])
+	}
+
+	buf.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/employees/42", nil))
+	assert.Contains(t, buf.String(), `"status":405`)
+}
+
+func TestAccessLogRemoteIP(t *testing.T) {
+	a, err := newAccessLog(AccessLogConfig{TrustedProxies: []string{"127.0.0.1", "10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		remote string
+		xff    string
+		real   string
+		ip     string
+	}
+
+	testCases := []testCase{
+		{"203.0.113.1:80", "198.51.100.1", "", "203.0.113.1"},
+		{"127.0.0.1:80", "198.51.100.1", "", "198.51.100.1"},
+		{"127.0.0.1:80", "198.51.100.9, 198.51.100.1, 10.1.1.1", "", "198.51.100.1"},
+		{"127.0.0.1:80", "", "198.51.100.2", "198.51.100.2"},
+		{"127.0.0.1:80", "", "", "127.0.0.1"},
+	}
+
+	for i, test := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remote
+		if test.xff != "" {
+			req.Header.Set("X-Forwarded-For", test.xff)
+		}
+		if test.real != "" {
+			req.Header.Set("X-Real-IP", test.real)
+		}
+
+		assert.Equal(t, test.ip, a.remoteIP(req), "test = %v", i)
+	}
+}
+
+func TestSetAccessLog_Invalid(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	assert.Error(t, mw.SetAccessLog(AccessLogConfig{SampleRate: 2}))
+	assert.Error(t, mw.SetAccessLog(AccessLogConfig{TrustedProxies: []string{"not ip"}}))
+}
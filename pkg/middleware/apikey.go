@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyHeader - header with api key
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrUnauthorized - request without valid credentials
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden - credentials do not grant required scope
+	ErrForbidden = errors.New("forbidden")
+)
+
+// APIKeyAuthenticator - checks api key and returns its owner
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (auth.Principal, error)
+}
+
+// APIKeyMiddleware - authenticates requests with X-API-Key header.
+// Requests without the header are passed through untouched so other
+// authentication methods can be applied, RequireScope rejects anonymous ones.
+func (mw *Middleware) APIKeyMiddleware(a APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, err := a.AuthenticateAPIKey(r.Context(), key)
+			if err != nil {
+				mw.getLogger(r).WithError(err).WithField("URL", r.URL.Path).Warn("api key authentication")
+				if !errors.Is(err, auth.ErrInvalidCredentials) {
+					utils.RespondWithError(w, r, http.StatusInternalServerError, errors.New("internal error"))
+					return
+				}
+				utils.RespondWithError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, withPrincipal(r, p))
+		})
+	}
+}
+
+// withPrincipal puts principal to request context and adds it to request scoped logger
+func withPrincipal(r *http.Request, p auth.Principal) *http.Request {
+	ctx := auth.WithPrincipal(r.Context(), p)
+	ctx = logger.WithFields(ctx, logrus.Fields{"user": p.Subject})
+	return r.WithContext(ctx)
+}
+
+// RequireScope - rejects requests which principal has no scope
+func (mw *Middleware) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := auth.GetPrincipal(r.Context())
+			if !ok {
+				utils.RespondWithError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+				return
+			}
+
+			if !p.HasScope(scope) {
+				utils.RespondWithError(w, r, http.StatusForbidden, fmt.Errorf("%w: scope %s required", ErrForbidden, scope))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
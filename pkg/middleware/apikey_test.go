@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type authenticatorMock struct {
+	key string
+	err error
+}
+
+func (a *authenticatorMock) AuthenticateAPIKey(ctx context.Context, key string) (auth.Principal, error) {
+	if a.err != nil {
+		return auth.Principal{}, a.err
+	}
+	if key != a.key {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return auth.Principal{Subject: "test", Method: auth.MethodAPIKey, Scopes: []string{"admin"}}, nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	handler := mw.APIKeyMiddleware(&authenticatorMock{key: "secret"})(
+		mw.RequireScope("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := auth.GetPrincipal(r.Context())
+			assert.True(t, ok)
+			assert.Equal(t, "test", p.Subject)
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	type testCase struct {
+		key    string
+		status int
+	}
+
+	testCases := []testCase{
+		{"secret", http.StatusOK},
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+	}
+
+	for i, test := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.key != "" {
+			req.Header.Set(APIKeyHeader, test.key)
+		}
+		res := httptest.NewRecorder()
+
+		handler.ServeHTTP(res, req)
+
+		if status := res.Code; status != test.status {
+			t.Errorf("test = %v, handler returned wrong status code: got %v want %v", i, status, test.status)
+		}
+	}
+}
+
+func TestAPIKeyMiddleware_InternalError(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	handler := mw.APIKeyMiddleware(&authenticatorMock{err: errors.New("db is down")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "secret")
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func TestRequireScope_Forbidden(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	handler := mw.RequireScope("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Scopes: []string{"employees:read"}}))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Code)
+}
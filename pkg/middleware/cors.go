@@ -4,47 +4,208 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-)
+	"time"
 
-var (
-	corsData = CorsData{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{
-			http.MethodGet,
-			http.MethodPost,
-			http.MethodPut,
-			http.MethodDelete,
-			http.MethodHead,
-			http.MethodOptions,
-		},
-		AllowHeaders: []string{
-			"Content-Type",
-			"X-Content-Type-Options",
-			"X-Csrf-Token",
-		},
-		AllowCredentials: true,
-	}
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultCorsData - policy used until SetCORS is called: any origin without credentials
+var DefaultCorsData = CorsData{
+	AllowOrigins: []string{"*"},
+	AllowMethods: []string{
+		http.MethodGet,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodDelete,
+		http.MethodHead,
+		http.MethodOptions,
+	},
+	AllowHeaders: []string{
+		"Content-Type",
+		"X-Content-Type-Options",
+		"X-Csrf-Token",
+		"Authorization",
+		APIKeyHeader,
+		RequestIDHeader,
+	},
+	ExposeHeaders: []string{
+		RequestIDHeader,
+	},
+}
+
 // CorsData - структура конфигурации CORS
 type CorsData struct {
-	AllowOrigins     []string
-	AllowMethods     []string
-	AllowHeaders     []string
-	AllowCredentials bool
+	// AllowOrigins - exact origins, "*" or wildcard subdomains like "https://*.example.com"
+	AllowOrigins     []string      `yaml:"allow_origins" json:"allow_origins" toml:"allow_origins"`
+	AllowMethods     []string      `yaml:"allow_methods" json:"allow_methods" toml:"allow_methods"`
+	AllowHeaders     []string      `yaml:"allow_headers" json:"allow_headers" toml:"allow_headers"`
+	ExposeHeaders    []string      `yaml:"expose_headers" json:"expose_headers" toml:"expose_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials" json:"allow_credentials" toml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age" json:"max_age" toml:"max_age"`
+}
+
+type originPattern struct {
+	prefix, suffix string
+}
+
+// corsPolicy - CorsData prepared for matching
+type corsPolicy struct {
+	data     CorsData
+	any      bool
+	exact    map[string]struct{}
+	patterns []originPattern
+	methods  map[string]struct{}
+	headers  map[string]struct{}
+}
+
+func newCorsPolicy(c CorsData) *corsPolicy {
+	p := &corsPolicy{
+		data:    c,
+		exact:   map[string]struct{}{},
+		methods: map[string]struct{}{},
+		headers: map[string]struct{}{},
+	}
+
+	for _, o := range c.AllowOrigins {
+		o = strings.ToLower(o)
+		switch i := strings.Index(o, "*"); {
+		case o == "*":
+			p.any = true
+		case i >= 0:
+			p.patterns = append(p.patterns, originPattern{prefix: o[:i], suffix: o[i+1:]})
+		default:
+			p.exact[o] = struct{}{}
+		}
+	}
+
+	for _, m := range c.AllowMethods {
+		p.methods[strings.ToUpper(m)] = struct{}{}
+	}
+
+	for _, h := range c.AllowHeaders {
+		p.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	return p
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.any {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if _, ok := p.exact[origin]; ok {
+		return true
+	}
+
+	for _, pt := range p.patterns {
+		// "https://*.example.com" matches "https://api.example.com" but not "https://.example.com"
+		if len(origin) > len(pt.prefix)+len(pt.suffix) &&
+			strings.HasPrefix(origin, pt.prefix) && strings.HasSuffix(origin, pt.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		if _, ok := p.headers[http.CanonicalHeaderKey(h)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcard reports whether "*" can be answered instead of reflecting origin,
+// browsers reject "*" for requests with credentials
+func (p *corsPolicy) wildcard() bool {
+	return p.any && !p.data.AllowCredentials
+}
+
+// SetCORS - replaces CORS policy, safe to call while serving requests
+func (mw *Middleware) SetCORS(c CorsData) {
+	mw.cors.Store(newCorsPolicy(c))
+}
+
+func (mw *Middleware) corsPolicy() *corsPolicy {
+	return mw.cors.Load().(*corsPolicy)
 }
 
 // CORSMiddleware - CORS middleware
 func (mw *Middleware) CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_ = r.Header.Get("Origin")
-		w.Header().Set("Access-Control-Allow-Origin", strings.Join(corsData.AllowOrigins, ", "))
-		if r.Method == http.MethodOptions {
-			w.Header().Set("Access-Control-Allow-Methods", strings.Join(corsData.AllowMethods, ", "))
-			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsData.AllowHeaders, ", "))
-			w.Header().Set("Access-Control-Allow-Credentials", strconv.FormatBool(corsData.AllowCredentials))
+		policy := mw.corsPolicy()
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if policy.wildcard() {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				mw.getLogger(r).WithField("origin", origin).Debug("cors: origin is not allowed")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !policy.wildcard() {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if policy.data.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(policy.data.ExposeHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(policy.data.ExposeHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
 			return
 		}
-		next.ServeHTTP(w, r)
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+		requested := r.Header.Get("Access-Control-Request-Headers")
+
+		if _, ok := policy.methods[method]; !ok || !policy.allowHeaders(requested) {
+			mw.getLogger(r).WithFields(logrus.Fields{
+				"origin":  origin,
+				"method":  method,
+				"headers": requested,
+			}).Debug("cors: preflight is not allowed")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.data.AllowMethods, ", "))
+		if len(policy.data.AllowHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.data.AllowHeaders, ", "))
+		}
+		if policy.data.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.data.MaxAge.Seconds())))
+		}
+
+		w.WriteHeader(http.StatusNoContent)
 	})
 }
@@ -5,7 +5,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,25 +30,38 @@ func TestCORSMiddlewareOrigin(t *testing.T) {
 	}
 
 	assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, res.Header().Get("Access-Control-Allow-Credentials"))
 }
 
 func TestCORSMiddlewareOptions(t *testing.T) {
 	mw := InitMiddleware(logrus.New())
+	mw.SetCORS(CorsData{
+		AllowOrigins:     []string{"https://example.com"},
+		AllowMethods:     DefaultCorsData.AllowMethods,
+		AllowHeaders:     DefaultCorsData.AllowHeaders,
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
 	handler := mw.CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+		t.Error("preflight must not reach handler")
 	}))
 
 	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-csrf-token")
 	res := httptest.NewRecorder()
 
 	handler.ServeHTTP(res, req)
 
-	if status := res.Code; status != http.StatusOK {
+	if status := res.Code; status != http.StatusNoContent {
 		t.Errorf("handler returned wrong status code: got %v want %v",
-			status, http.StatusOK)
+			status, http.StatusNoContent)
 	}
 
-	assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, res.Header().Values("Vary"), "Origin")
+	assert.Equal(t, "600", res.Header().Get("Access-Control-Max-Age"))
 
 	methods := res.Header().Get("Access-Control-Allow-Methods")
 	assert.NotEmpty(t, methods)
@@ -54,7 +70,6 @@ func TestCORSMiddlewareOptions(t *testing.T) {
 	assert.True(t, strings.Contains(methods, http.MethodPut))
 	assert.True(t, strings.Contains(methods, http.MethodDelete))
 	assert.True(t, strings.Contains(methods, http.MethodHead))
-	assert.True(t, strings.Contains(methods, http.MethodHead))
 
 	headers := res.Header().Get("Access-Control-Allow-Headers")
 	assert.NotEmpty(t, headers)
@@ -66,3 +81,125 @@ func TestCORSMiddlewareOptions(t *testing.T) {
 	assert.NotEmpty(t, credentials)
 	assert.Equal(t, credentials, "true")
 }
+
+func TestCORSMiddlewareAllowList(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	mw.SetCORS(CorsData{
+		AllowOrigins:     []string{"https://example.com", "https://*.example.org"},
+		AllowMethods:     []string{http.MethodGet},
+		AllowHeaders:     []string{"Content-Type"},
+		ExposeHeaders:    []string{"X-Request-Id"},
+		AllowCredentials: true,
+	})
+	handler := mw.CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	type testCase struct {
+		origin  string
+		allowed bool
+	}
+
+	testCases := []testCase{
+		{"https://example.com", true},
+		{"https://api.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"http://api.example.org", false},
+		{"https://evil.com", false},
+		{"https://example.com.evil.com", false},
+	}
+
+	for _, test := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", test.origin)
+		res := httptest.NewRecorder()
+
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code, test.origin)
+		assert.Contains(t, res.Header().Values("Vary"), "Origin", test.origin)
+		if test.allowed {
+			assert.Equal(t, test.origin, res.Header().Get("Access-Control-Allow-Origin"), test.origin)
+			assert.Equal(t, "true", res.Header().Get("Access-Control-Allow-Credentials"), test.origin)
+			assert.Equal(t, "X-Request-Id", res.Header().Get("Access-Control-Expose-Headers"), test.origin)
+		} else {
+			assert.Empty(t, res.Header().Get("Access-Control-Allow-Origin"), test.origin)
+			assert.Empty(t, res.Header().Get("Access-Control-Allow-Credentials"), test.origin)
+		}
+	}
+}
+
+func TestCORSMiddlewarePreflightRejected(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	mw.SetCORS(CorsData{
+		AllowOrigins: []string{"https://example.com"},
+		AllowMethods: []string{http.MethodGet},
+		AllowHeaders: []string{"Content-Type"},
+	})
+	handler := mw.CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("preflight must not reach handler")
+	}))
+
+	type testCase struct {
+		origin  string
+		method  string
+		headers string
+	}
+
+	testCases := []testCase{
+		{"https://evil.com", http.MethodGet, ""},
+		{"https://example.com", http.MethodDelete, ""},
+		{"https://example.com", http.MethodGet, "X-Custom"},
+	}
+
+	for i, test := range testCases {
+		req := httptest.NewRequest(http.MethodOptions, "/", nil)
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Access-Control-Request-Method", test.method)
+		if test.headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", test.headers)
+		}
+		res := httptest.NewRecorder()
+
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusForbidden, res.Code, "test = %v", i)
+		assert.Empty(t, res.Header().Get("Access-Control-Allow-Methods"), "test = %v", i)
+	}
+}
+
+func TestCORSMiddlewareRouter(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	mw.SetCORS(CorsData{
+		AllowOrigins: []string{"https://example.com"},
+		AllowMethods: []string{http.MethodGet, http.MethodPut},
+	})
+
+	// routes do not accept OPTIONS, mux answers preflight with 405 bypassing router.Use
+	router := mux.NewRouter()
+	router.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet, http.MethodPut)
+	handler := utils.WrapRouter(router, mw.CORSMiddleware)
+
+	req := httptest.NewRequest(http.MethodOptions, "/employees/42", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, PUT", res.Header().Get("Access-Control-Allow-Methods"))
+
+	req = httptest.NewRequest(http.MethodGet, "/employees/42", nil)
+	req.Header.Set("Origin", "https://example.com")
+	res = httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/moguchev/service/pkg/utils"
+)
+
+const bearerPrefix = "Bearer "
+
+// TokenAuthenticator - checks bearer access token and returns its owner
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (auth.Principal, error)
+}
+
+// JWTMiddleware - authenticates requests with "Authorization: Bearer" header.
+// Requests without the header (or already authenticated with api key) are passed through.
+func (mw *Middleware) JWTMiddleware(a TokenAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if _, ok := auth.GetPrincipal(r.Context()); ok || header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !strings.HasPrefix(header, bearerPrefix) {
+				utils.RespondWithError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+				return
+			}
+
+			p, err := a.AuthenticateToken(r.Context(), strings.TrimPrefix(header, bearerPrefix))
+			if err != nil {
+				mw.getLogger(r).WithError(err).WithField("URL", r.URL.Path).Warn("token authentication")
+				if !errors.Is(err, auth.ErrInvalidCredentials) {
+					utils.RespondWithError(w, r, http.StatusInternalServerError, errors.New("internal error"))
+					return
+				}
+				utils.RespondWithError(w, r, http.StatusUnauthorized, ErrUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, withPrincipal(r, p))
+		})
+	}
+}
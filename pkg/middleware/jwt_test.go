@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenAuthenticatorMock struct{}
+
+func (a *tokenAuthenticatorMock) AuthenticateToken(ctx context.Context, token string) (auth.Principal, error) {
+	if token != "valid" {
+		return auth.Principal{}, auth.ErrInvalidCredentials
+	}
+	return auth.Principal{Subject: "42", Method: auth.MethodJWT}, nil
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	handler := mw.JWTMiddleware(&tokenAuthenticatorMock{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.GetPrincipal(r.Context()); ok {
+			w.Header().Set("X-Subject", p.Subject)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	type testCase struct {
+		header  string
+		status  int
+		subject string
+	}
+
+	testCases := []testCase{
+		{"Bearer valid", http.StatusOK, "42"},
+		{"", http.StatusOK, ""},
+		{"Bearer invalid", http.StatusUnauthorized, ""},
+		{"Basic dXNlcjpwYXNz", http.StatusUnauthorized, ""},
+	}
+
+	for i, test := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+		res := httptest.NewRecorder()
+
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, test.status, res.Code, "test = %v", i)
+		assert.Equal(t, test.subject, res.Header().Get("X-Subject"), "test = %v", i)
+	}
+}
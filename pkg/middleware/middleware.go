@@ -1,15 +1,34 @@
 package middleware
 
-import "github.com/sirupsen/logrus"
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
 
 // Middleware - represent the data-struct for middleware
 type Middleware struct {
-	log *logrus.Logger
+	log    *logrus.Logger
+	cors   atomic.Value // *corsPolicy
+	access atomic.Value // *accessLog
 }
 
 // InitMiddleware - initialize the middleware
 func InitMiddleware(l *logrus.Logger) *Middleware {
-	return &Middleware{
+	mw := &Middleware{
 		log: l,
 	}
+	mw.SetCORS(DefaultCorsData)
+	_ = mw.SetAccessLog(AccessLogConfig{})
+	return mw
+}
+
+// getLogger returns request scoped logger if any
+func (mw *Middleware) getLogger(r *http.Request) *logrus.Entry {
+	if e, ok := logger.FromContext(r.Context()); ok {
+		return e
+	}
+	return logrus.NewEntry(mw.log)
 }
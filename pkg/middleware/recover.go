@@ -9,7 +9,7 @@ func (mw *Middleware) RecoverMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				mw.log.WithField("URL", r.URL.Path).Errorf("recover %v", err)
+				mw.getLogger(r).WithField("URL", r.URL.Path).Errorf("recover %v", err)
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
 			}
 		}()
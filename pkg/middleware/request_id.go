@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIDHeader - header with request id
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDField - logger field with request id
+	RequestIDField = "request_id"
+
+	maxRequestIDLen = 128
+)
+
+type ctxrequestid struct{}
+
+// GetRequestID get request id from context
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxrequestid{}).(string)
+	return id
+}
+
+// validRequestID accepts only short printable ids so they are safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestIDMiddleware - accepts or generates X-Request-ID, echoes it in response and
+// puts request scoped logger (request id, method, path) to context
+func (mw *Middleware) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), ctxrequestid{}, id)
+		ctx = logger.WithFields(ctx, logrus.Fields{
+			RequestIDField: id,
+			"method":       r.Method,
+			"path":         r.URL.Path,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
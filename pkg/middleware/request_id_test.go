@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/moguchev/service/pkg/auth"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	mw := InitMiddleware(logrus.New())
+	handler := mw.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(GetRequestID(r.Context())))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, "abc-123", res.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc-123", res.Body.String())
+
+	for _, id := range []string{"", "with space", strings.Repeat("a", maxRequestIDLen+1)} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, id)
+		res = httptest.NewRecorder()
+
+		handler.ServeHTTP(res, req)
+
+		generated := res.Header().Get(RequestIDHeader)
+		assert.NotEmpty(t, generated)
+		assert.NotEqual(t, id, generated)
+		assert.Equal(t, generated, res.Body.String())
+	}
+}
+
+func TestRequestIDMiddlewareLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+
+	mw := InitMiddleware(l)
+	handler := mw.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = withPrincipal(r, auth.Principal{Subject: "42"})
+		logger.GetLogger(r.Context()).WithField("actor", "repository").Info("query")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/employees", nil)
+	req = req.WithContext(logger.WithLogger(req.Context(), l))
+	req.Header.Set(RequestIDHeader, "abc-123")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("unexpected log output %q: %v", buf.String(), err)
+	}
+
+	assert.Equal(t, "abc-123", fields[RequestIDField])
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, "/employees", fields["path"])
+	assert.Equal(t, "42", fields["user"])
+	assert.Equal(t, "repository", fields["actor"])
+}
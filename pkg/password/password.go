@@ -0,0 +1,135 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// algorithms
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+
+	argon2idPrefix = "$argon2id$"
+	argon2SaltLen  = 16
+	argon2KeyLen   = 32
+)
+
+var (
+	// ErrMismatch - password does not match the hash
+	ErrMismatch = errors.New("password mismatch")
+	// ErrUnknownHash - hash was produced by unsupported algorithm
+	ErrUnknownHash = errors.New("unknown hash format")
+)
+
+// Config is a configuration for password hashing
+type Config struct {
+	Algorithm   string `yaml:"algorithm" json:"algorithm" toml:"algorithm"` // enum (bcrypt|argon2id)
+	BcryptCost  int    `yaml:"bcrypt_cost" json:"bcrypt_cost" toml:"bcrypt_cost"`
+	Argon2Time  uint32 `yaml:"argon2_time" json:"argon2_time" toml:"argon2_time"`
+	Argon2KiB   uint32 `yaml:"argon2_memory" json:"argon2_memory" toml:"argon2_memory"`
+	Argon2Procs uint8  `yaml:"argon2_threads" json:"argon2_threads" toml:"argon2_threads"`
+}
+
+// Hasher hashes new passwords with configured algorithm and verifies
+// hashes produced by any supported one, so algorithm can be changed safely
+type Hasher struct {
+	cfg Config
+}
+
+// CreateHasher returns hasher according config
+func (cfg Config) CreateHasher() (*Hasher, error) {
+	switch cfg.Algorithm {
+	case "":
+		cfg.Algorithm = Bcrypt
+	case Bcrypt, Argon2id:
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.DefaultCost
+	}
+	if cfg.Argon2Time == 0 {
+		cfg.Argon2Time = 1
+	}
+	if cfg.Argon2KiB == 0 {
+		cfg.Argon2KiB = 64 * 1024
+	}
+	if cfg.Argon2Procs == 0 {
+		cfg.Argon2Procs = 2
+	}
+
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash returns encoded hash of password
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == Argon2id {
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, h.cfg.Argon2Time, h.cfg.Argon2KiB, h.cfg.Argon2Procs, argon2KeyLen)
+		return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+			h.cfg.Argon2KiB, h.cfg.Argon2Time, h.cfg.Argon2Procs,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify checks password against encoded hash
+func (h *Hasher) Verify(hash, password string) error {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	}
+
+	var (
+		version      int
+		memory, time uint32
+		threads      uint8
+	)
+
+	parts := strings.Split(strings.TrimPrefix(hash, argon2idPrefix), "$")
+	if len(parts) != 4 {
+		return ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return ErrUnknownHash
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
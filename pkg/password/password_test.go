@@ -0,0 +1,59 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashVerify(t *testing.T) {
+	for _, algorithm := range []string{Bcrypt, Argon2id} {
+		h, err := Config{Algorithm: algorithm, BcryptCost: bcrypt.MinCost, Argon2KiB: 1024}.CreateHasher()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", algorithm, err)
+		}
+
+		hash, err := h.Hash("password")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", algorithm, err)
+		}
+
+		if strings.Contains(hash, "password") {
+			t.Errorf("%s: hash contains password", algorithm)
+		}
+
+		if err = h.Verify(hash, "password"); err != nil {
+			t.Errorf("%s: unexpected error: %v", algorithm, err)
+		}
+
+		if err = h.Verify(hash, "wrong"); !errors.Is(err, ErrMismatch) {
+			t.Errorf("%s: expected error: %v, got: %v", algorithm, ErrMismatch, err)
+		}
+	}
+}
+
+func TestVerify_OtherAlgorithm(t *testing.T) {
+	bh, _ := Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost}.CreateHasher()
+	ah, _ := Config{Algorithm: Argon2id, Argon2KiB: 1024}.CreateHasher()
+
+	hash, err := bh.Hash("password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = ah.Verify(hash, "password"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err = ah.Verify("$argon2id$broken", "password"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("expected error: %v, got: %v", ErrUnknownHash, err)
+	}
+}
+
+func TestCreateHasher_Unknown(t *testing.T) {
+	if _, err := (Config{Algorithm: "md5"}).CreateHasher(); err == nil {
+		t.Error("expected error")
+	}
+}
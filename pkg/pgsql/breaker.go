@@ -0,0 +1,112 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBreakerThreshold   = 5
+	DefaultBreakerOpenTimeout = 10 * time.Second
+)
+
+// ErrCircuitOpen - database is considered down, calls fail without trying it
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+// BreakerConfig is a configuration for circuit breaker
+type BreakerConfig struct {
+	// Threshold - consecutive connection failures opening circuit
+	Threshold int `yaml:"threshold" json:"threshold" toml:"threshold"`
+	// OpenTimeout - time after which one probe call is let through
+	OpenTimeout time.Duration `yaml:"open_timeout" json:"open_timeout" toml:"open_timeout"`
+}
+
+type breakerState int
+
+const (
+	closed breakerState = iota
+	open
+	halfOpen
+)
+
+// Breaker - circuit breaker. It opens after Threshold consecutive failures and fails
+// calls fast, after OpenTimeout one probe call is allowed and closes it on success.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	timeout   time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// CreateBreaker returns circuit breaker according config
+func (cfg BreakerConfig) CreateBreaker() *Breaker {
+	b := &Breaker{threshold: cfg.Threshold, timeout: cfg.OpenTimeout, now: time.Now}
+
+	if b.threshold == 0 {
+		b.threshold = DefaultBreakerThreshold
+	}
+
+	if b.timeout == 0 {
+		b.timeout = DefaultBreakerOpenTimeout
+	}
+
+	return b
+}
+
+// Allow returns ErrCircuitOpen if call must not be made
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == closed {
+		return nil
+	}
+
+	// probe without result, e.g. canceled, is replaced after timeout too
+	if b.now().Sub(b.openedAt) < b.timeout {
+		return ErrCircuitOpen
+	}
+
+	// the caller is the probe, others fail until its result
+	b.state = halfOpen
+	b.openedAt = b.now()
+	return nil
+}
+
+// Success records call which reached database
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = closed
+	b.failures = 0
+}
+
+// Failure records call failed by connection error
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == halfOpen || b.failures >= b.threshold {
+		b.state = open
+		b.openedAt = b.now()
+	}
+}
+
+// Check returns ErrCircuitOpen while circuit is not closed. It is used as degraded
+// readiness check: reads fail fast, but instance keeps serving other requests.
+func (b *Breaker) Check(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != closed {
+		return ErrCircuitOpen
+	}
+	return nil
+}
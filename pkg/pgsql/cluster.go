@@ -0,0 +1,221 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultReplicaCheckInterval - how often replicas health and lag are checked
+	DefaultReplicaCheckInterval = 5 * time.Second
+	// replicaCheckTimeout - unresponsive replica is excluded after it
+	replicaCheckTimeout = 2 * time.Second
+)
+
+// ErrReplicaNotStreaming - replica does not receive WAL from primary, so its data gets stale
+var ErrReplicaNotStreaming = errors.New("replica WAL receiver is not streaming")
+
+// replicationLagQuery returns seconds since the last replayed transaction,
+// lag is zero when all received WAL is replayed, e.g. primary has no writes.
+// It is NULL when WAL receiver is not streaming, as received position is not
+// advanced then and disconnected replica would look fresh forever.
+const replicationLagQuery = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN NOT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') THEN NULL
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+type replica struct {
+	name    string
+	db      *sqlx.DB
+	healthy int32 // atomic bool
+}
+
+// Cluster - primary database and read replicas. Reads are routed to healthy
+// replicas in round-robin order and to primary when there are none.
+type Cluster struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	next     uint32
+	maxLag   time.Duration
+	// checkTimeout - zero means replicaCheckTimeout
+	checkTimeout time.Duration
+}
+
+// CreateCluster returns primary and replicas connections according config,
+// replicas are considered healthy until the first check
+func (cfg Config) CreateCluster() (*Cluster, error) {
+	primary, err := cfg.CreateDB()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{primary: primary, maxLag: cfg.MaxReplicationLag}
+
+	for i, dsn := range cfg.Replicas {
+		rcfg := cfg
+		rcfg.Connection = dsn
+
+		db, err := rcfg.CreateDB()
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("replica %d: %w", i, err)
+		}
+
+		c.replicas = append(c.replicas, &replica{name: fmt.Sprintf("replica_%d", i), db: db, healthy: 1})
+	}
+
+	return c, nil
+}
+
+// Primary returns connection for writes and reads requiring latest data
+func (c *Cluster) Primary() *sqlx.DB {
+	return c.primary
+}
+
+// Replica returns connection of the next healthy replica or primary
+func (c *Cluster) Replica() *sqlx.DB {
+	n := len(c.replicas)
+	if n == 0 {
+		return c.primary
+	}
+
+	start := atomic.AddUint32(&c.next, 1)
+	for i := 0; i < n; i++ {
+		r := c.replicas[(int(start)+i)%n]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r.db
+		}
+	}
+
+	return c.primary
+}
+
+// Replicas returns replicas connections by names, e.g. to register metrics
+func (c *Cluster) Replicas() map[string]*sqlx.DB {
+	m := make(map[string]*sqlx.DB, len(c.replicas))
+	for _, r := range c.replicas {
+		m[r.name] = r.db
+	}
+	return m
+}
+
+// SetPool applies pool limits to all connections
+func (c *Cluster) SetPool(cfg Config) {
+	for _, db := range append([]*sqlx.DB{c.primary}, c.dbs()...) {
+		db.SetMaxOpenConns(cfg.MaxOpenConn)
+		db.SetMaxIdleConns(cfg.MaxIdleConn)
+		db.SetConnMaxLifetime(cfg.MaxConnLifetime)
+	}
+}
+
+func (c *Cluster) dbs() []*sqlx.DB {
+	list := make([]*sqlx.DB, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		list = append(list, r.db)
+	}
+	return list
+}
+
+// Check pings replicas concurrently and excludes unavailable ones, ones not answering
+// in time and ones lagging behind more than max lag
+func (c *Cluster) Check(ctx context.Context) {
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "pgsql",
+		"func":  "Check",
+	})
+
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+
+			err := c.checkReplica(ctx, r.db)
+
+			healthy := int32(1)
+			if err != nil {
+				healthy = 0
+			}
+
+			if atomic.SwapInt32(&r.healthy, healthy) != healthy {
+				if err != nil {
+					log.WithError(err).WithField("replica", r.name).Warn("replica excluded from reads")
+				} else {
+					log.WithField("replica", r.name).Info("replica returned to reads")
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+}
+
+func (c *Cluster) checkReplica(ctx context.Context, db *sqlx.DB) error {
+	timeout := c.checkTimeout
+	if timeout == 0 {
+		timeout = replicaCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lag sql.NullFloat64
+	if err := db.QueryRowContext(ctx, replicationLagQuery).Scan(&lag); err != nil {
+		return fmt.Errorf("get replication lag: %w", err)
+	}
+
+	if !lag.Valid {
+		return ErrReplicaNotStreaming
+	}
+
+	if c.maxLag > 0 && time.Duration(lag.Float64*float64(time.Second)) > c.maxLag {
+		return fmt.Errorf("replication lag %.1fs exceeds %v", lag.Float64, c.maxLag)
+	}
+
+	return nil
+}
+
+// Watch checks replicas periodically until ctx is done
+func (c *Cluster) Watch(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	if interval <= 0 {
+		interval = DefaultReplicaCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes all connections
+func (c *Cluster) Close() error {
+	err := c.primary.Close()
+	for _, r := range c.replicas {
+		if rerr := r.db.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
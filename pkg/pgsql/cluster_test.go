@@ -0,0 +1,135 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func newMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	return sqlx.NewDb(mockDB, "sqlmock"), mock
+}
+
+func TestCluster_Replica(t *testing.T) {
+	primary, _ := newMockDB(t)
+	defer primary.Close()
+	r1, mock1 := newMockDB(t)
+	defer r1.Close()
+	r2, mock2 := newMockDB(t)
+	defer r2.Close()
+
+	c := &Cluster{
+		primary: primary,
+		replicas: []*replica{
+			{name: "replica_0", db: r1, healthy: 1},
+			{name: "replica_1", db: r2, healthy: 1},
+		},
+		maxLag: 10 * time.Second,
+	}
+
+	first, second := c.Replica(), c.Replica()
+	if first == second || first == primary || second == primary {
+		t.Errorf("expected round-robin over replicas")
+	}
+
+	// replica_0 lags, replica_1 is down
+	mock1.ExpectQuery("SELECT CASE").WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(60.0))
+	mock2.ExpectQuery("SELECT CASE").WillReturnError(errors.New("connection refused"))
+	c.Check(context.Background())
+
+	if db := c.Replica(); db != primary {
+		t.Errorf("expected failover to primary")
+	}
+
+	mock1.ExpectQuery("SELECT CASE").WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(1.5))
+	mock2.ExpectQuery("SELECT CASE").WillReturnError(errors.New("connection refused"))
+	c.Check(context.Background())
+
+	for i := 0; i < 3; i++ {
+		if db := c.Replica(); db != r1 {
+			t.Errorf("expected the only healthy replica")
+		}
+	}
+
+	if err := mock1.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	if err := mock2.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCluster_Disconnected(t *testing.T) {
+	primary, _ := newMockDB(t)
+	defer primary.Close()
+	r1, mock1 := newMockDB(t)
+	defer r1.Close()
+
+	c := &Cluster{
+		primary:  primary,
+		replicas: []*replica{{name: "replica_0", db: r1, healthy: 1}},
+		maxLag:   10 * time.Second,
+	}
+
+	// WAL receiver is disconnected, received and replayed positions stay equal
+	mock1.ExpectQuery("SELECT CASE").WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(nil))
+	c.Check(context.Background())
+
+	if db := c.Replica(); db != primary {
+		t.Errorf("expected disconnected replica to be excluded")
+	}
+	if err := mock1.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCluster_CheckTimeout(t *testing.T) {
+	primary, _ := newMockDB(t)
+	defer primary.Close()
+	r1, mock1 := newMockDB(t)
+	defer r1.Close()
+	r2, mock2 := newMockDB(t)
+	defer r2.Close()
+
+	c := &Cluster{
+		primary: primary,
+		replicas: []*replica{
+			{name: "replica_0", db: r1, healthy: 1},
+			{name: "replica_1", db: r2, healthy: 1},
+		},
+		checkTimeout: 50 * time.Millisecond,
+	}
+
+	// both replicas are blackholed, they are checked concurrently
+	mock1.ExpectQuery("SELECT CASE").WillDelayFor(time.Minute).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.0))
+	mock2.ExpectQuery("SELECT CASE").WillDelayFor(time.Minute).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.0))
+
+	start := time.Now()
+	c.Check(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("check took %v", elapsed)
+	}
+
+	if db := c.Replica(); db != primary {
+		t.Errorf("expected failover to primary")
+	}
+}
+
+func TestCluster_NoReplicas(t *testing.T) {
+	primary, _ := newMockDB(t)
+	defer primary.Close()
+
+	c := &Cluster{primary: primary}
+	if c.Replica() != primary {
+		t.Errorf("expected primary without replicas")
+	}
+}
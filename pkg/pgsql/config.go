@@ -11,11 +11,42 @@ import (
 )
 
 type Config struct {
-	Connection      string        `yaml:"postgresql"`
-	MaxOpenConn     int           `yaml:"max_open_conn"`
-	MaxIdleConn     int           `yaml:"max_idle_conn"`
-	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
-	opts            options
+	Connection      string        `yaml:"postgresql" json:"postgresql" toml:"postgresql"`
+	MaxOpenConn     int           `yaml:"max_open_conn" json:"max_open_conn" toml:"max_open_conn"`
+	MaxIdleConn     int           `yaml:"max_idle_conn" json:"max_idle_conn" toml:"max_idle_conn"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" json:"max_conn_lifetime" toml:"max_conn_lifetime"`
+	// StartupTimeout - how long database is waited for on startup
+	StartupTimeout time.Duration `yaml:"startup_timeout" json:"startup_timeout" toml:"startup_timeout"`
+	// StartupMaxDelay - max delay between pings while waiting
+	StartupMaxDelay time.Duration `yaml:"startup_max_delay" json:"startup_max_delay" toml:"startup_max_delay"`
+	// Replicas - connection strings of read replicas, Connection is primary
+	Replicas []string `yaml:"replicas" json:"replicas" toml:"replicas"`
+	// MaxReplicationLag - replicas lagging behind more are excluded from reads, 0 means no limit
+	MaxReplicationLag    time.Duration `yaml:"max_replication_lag" json:"max_replication_lag" toml:"max_replication_lag"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" json:"replica_check_interval" toml:"replica_check_interval"`
+	// Backend - enum (sqlx|pgx), pgx uses native pool for employees repository without replicas
+	Backend string `yaml:"backend" json:"backend" toml:"backend"`
+	// MinConns, HealthCheckPeriod - settings of pgx pool only
+	MinConns          int32         `yaml:"min_conns" json:"min_conns" toml:"min_conns"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" json:"health_check_period" toml:"health_check_period"`
+	// TxIsolation - enum (read_committed|repeatable_read|serializable) default isolation of
+	// transactions, empty means database default
+	TxIsolation string `yaml:"tx_isolation" json:"tx_isolation" toml:"tx_isolation"`
+	// TxMaxRetries - retries of serialization failures and deadlocks, 0 means default, negative disables
+	TxMaxRetries int `yaml:"tx_max_retries" json:"tx_max_retries" toml:"tx_max_retries"`
+	// Retry - retries of reads failed by connection errors, nil disables, negative max_retries too
+	Retry *RetryConfig `yaml:"retry" json:"retry" toml:"retry"`
+	// Breaker - circuit breaker failing reads fast while database is down, nil disables
+	Breaker *BreakerConfig `yaml:"breaker" json:"breaker" toml:"breaker"`
+	// Migrations - enum (auto|check), check is used when migrations run as separate job
+	Migrations string `yaml:"migrations" json:"migrations" toml:"migrations"`
+	// MigrationLockTimeout - how long replica waits for another one migrating database
+	MigrationLockTimeout time.Duration `yaml:"migration_lock_timeout" json:"migration_lock_timeout" toml:"migration_lock_timeout"`
+	// ExtraMigrations - directories with optional migrations (seed or test data) applied
+	// after embedded schema, their versions are tracked apart in ExtraMigrationsTable
+	ExtraMigrations []string `yaml:"extra_migrations" json:"extra_migrations" toml:"extra_migrations"`
+
+	opts options
 }
 
 type options struct {
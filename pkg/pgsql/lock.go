@@ -0,0 +1,59 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MigrationLockKey - advisory lock key serializing migrations of all replicas.
+// It differs from the key used by golang-migrate itself, which is taken per migrator.
+const MigrationLockKey int64 = 0x6d6f6775636865 // "moguche"
+
+// lockRetryInterval - how often lock is retried while it is held by another replica
+const lockRetryInterval = 500 * time.Millisecond
+
+// ErrLockTimeout - advisory lock was not acquired in time
+var ErrLockTimeout = errors.New("advisory lock timeout")
+
+// WithAdvisoryLock runs fn holding session advisory lock key, waiting for it up to timeout.
+// Lock is taken on dedicated connection and released when fn returns. Connection is passed
+// to fn, so that fn is able to query without another one from limited pool.
+func WithAdvisoryLock(ctx context.Context, db *sqlx.DB, key int64, timeout time.Duration, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var locked bool
+		if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+			return fmt.Errorf("try advisory lock: %w", err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("key %d after %v: %w", key, timeout, ErrLockTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	defer func() {
+		// connection returns to pool, so lock must be released explicitly
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+	}()
+
+	return fn(conn)
+}
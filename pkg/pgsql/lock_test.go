@@ -0,0 +1,63 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestWithAdvisoryLock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(MigrationLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(MigrationLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(MigrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	called := false
+	err = WithAdvisoryLock(context.Background(), db, MigrationLockKey, time.Second, func(*sql.Conn) error {
+		called = true
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Errorf("fn was not called")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithAdvisoryLock_Timeout(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create mock: %s", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(MigrationLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	err = WithAdvisoryLock(context.Background(), db, MigrationLockKey, 0, func(*sql.Conn) error {
+		t.Errorf("fn must not be called without lock")
+		return nil
+	})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("expected ErrLockTimeout, got: %v", err)
+	}
+}
@@ -1,12 +1,17 @@
 package pgsql
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"io/fs"
 	"os"
-	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -14,45 +19,322 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-func EnsureDB(db *sqlx.DB, assets http.FileSystem) error {
-	dbdrv, err := postgres.WithInstance(db.DB, &postgres.Config{})
-	if err != nil {
-		return err
-	}
+const (
+	// MigrationsAuto - migrations are applied on startup
+	MigrationsAuto = "auto"
+	// MigrationsCheck - migrations are applied by separate job, startup only checks schema
+	MigrationsCheck = "check"
+
+	DefaultMigrationLockTimeout = time.Minute
+
+	// SchemaMigrationsTable - versions of embedded schema migrations
+	SchemaMigrationsTable = "schema_migrations"
+	// ExtraMigrationsTable - versions of extra migrations, which have own sequence,
+	// so that schema and extra migrations do not skip each other
+	ExtraMigrationsTable = "schema_migrations_extra"
+)
+
+var (
+	// ErrSchemaNewer - database was migrated by newer binary
+	ErrSchemaNewer = errors.New("database schema is newer than migrations of this binary")
+)
+
+// migratorMaxOpenConns - connections of migrator: one is pinned by migrate driver,
+// another one holds migration advisory lock and checks schema
+const migratorMaxOpenConns = 2
+
+// RowQuerier - database or single connection running queries returning one row
+type RowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Migrator - golang-migrate migrator on its own connections, which are released by Close,
+// so that migrations neither take connections of application pool nor leak them
+type Migrator struct {
+	m  *migrate.Migrate
+	db *sqlx.DB
+}
+
+// NewMigrator opens dedicated connections according config and returns migrator
+// applying migrations from assets, versions table is created if not exists
+func (cfg Config) NewMigrator(assets fs.FS, table string) (*Migrator, error) {
 	s, err := Source{
 		Assets: assets,
 	}.Open("")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	migrator, err := migrate.NewWithInstance("vfs", s, "postgres", dbdrv)
+
+	db, err := cfg.CreateDB()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	db.SetMaxOpenConns(migratorMaxOpenConns)
 
-	return migrator.Up()
+	// driver takes ownership of db and closes it
+	dbdrv, err := postgres.WithInstance(db.DB, &postgres.Config{MigrationsTable: table})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("fs", s, "postgres", dbdrv)
+	if err != nil {
+		dbdrv.Close()
+		return nil, err
+	}
+
+	return &Migrator{m: m, db: db}, nil
+}
+
+// Up applies all migrations
+func (m *Migrator) Up() error {
+	return m.m.Up()
+}
+
+// Steps applies n migrations up, or -n down if n is negative
+func (m *Migrator) Steps(n int) error {
+	return m.m.Steps(n)
 }
 
+// Migrate migrates up or down to version
+func (m *Migrator) Migrate(version uint) error {
+	return m.m.Migrate(version)
+}
+
+// Force sets version without running migrations, e.g. to fix dirty state
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Version returns applied version, migrate.ErrNilVersion if none
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	return m.m.Version()
+}
+
+// WithLock runs fn holding migration advisory lock, waiting for it up to timeout
+func (m *Migrator) WithLock(ctx context.Context, timeout time.Duration, fn func() error) error {
+	return WithAdvisoryLock(ctx, m.db, MigrationLockKey, timeout, func(*sql.Conn) error {
+		return fn()
+	})
+}
+
+// Close releases migrator connections
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// EnsureDB applies schema migrations and extra ones from config as one set ordered by
+// version, schema migration goes first on equal versions. Each set keeps own versions
+// table, so that binaries without extra migrations see only schema version. Migrations
+// are applied holding advisory lock, so that concurrently started replicas migrate one
+// by one. It refuses to downgrade schema newer than assets.
+func (cfg Config) EnsureDB(ctx context.Context, assets fs.FS) error {
+	m, err := cfg.NewMigrator(assets, SchemaMigrationsTable)
+	if err != nil {
+		return fmt.Errorf("init migrator: %w", err)
+	}
+	defer m.Close()
+
+	sets := []migrationSet{{assets: assets, table: SchemaMigrationsTable, migrate: m.Migrate}}
+
+	if extra := cfg.ExtraMigrationAssets(); extra != nil {
+		em, err := cfg.NewMigrator(extra, ExtraMigrationsTable)
+		if err != nil {
+			return fmt.Errorf("init extra migrator: %w", err)
+		}
+		defer em.Close()
+
+		sets = append(sets, migrationSet{assets: extra, table: ExtraMigrationsTable, migrate: em.Migrate})
+	}
+
+	return ensureLocked(ctx, m.db, cfg.migrationLockTimeout(), sets)
+}
+
+// migrationSet - migrations tracked in own versions table
+type migrationSet struct {
+	assets  fs.FS
+	table   string
+	migrate func(version uint) error
+}
+
+// migrationStep - pending migration of set
+type migrationStep struct {
+	set     int
+	version uint
+}
+
+// ensureLocked checks schema and applies pending migrations of all sets ordered by version
+// holding migration advisory lock. Schema is checked on the lock connection, as the other
+// one of migrator is pinned by driver.
+func ensureLocked(ctx context.Context, db *sqlx.DB, timeout time.Duration, sets []migrationSet) error {
+	return WithAdvisoryLock(ctx, db, MigrationLockKey, timeout, func(conn *sql.Conn) error {
+		var steps []migrationStep
+
+		for i, set := range sets {
+			versions, err := migrationVersions(set.assets)
+			if err != nil {
+				return fmt.Errorf("%s: %w", set.table, err)
+			}
+			if len(versions) == 0 {
+				continue
+			}
+
+			applied, err := checkedVersion(ctx, conn, set.table, versions[len(versions)-1])
+			if err != nil {
+				return fmt.Errorf("%s: %w", set.table, err)
+			}
+			for _, v := range versions {
+				if v > applied {
+					steps = append(steps, migrationStep{set: i, version: v})
+				}
+			}
+		}
+
+		// sets are listed in priority order, so stable sort keeps it for equal versions
+		sort.SliceStable(steps, func(i, j int) bool { return steps[i].version < steps[j].version })
+
+		for _, s := range steps {
+			if err := sets[s.set].migrate(s.version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+				return fmt.Errorf("%s: version %d: %w", sets[s.set].table, s.version, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// CheckSchema returns error if database is dirty or its version in table is newer
+// than assets. It only reads table, so database is not changed.
+func CheckSchema(ctx context.Context, db RowQuerier, assets fs.FS, table string) error {
+	latest, err := LatestVersion(assets)
+	if err != nil {
+		return fmt.Errorf("get latest migration: %w", err)
+	}
+
+	_, err = checkedVersion(ctx, db, table, latest)
+	return err
+}
+
+// checkedVersion returns version applied from table, 0 if none, or error if database
+// is dirty or its version is newer than latest
+func checkedVersion(ctx context.Context, db RowQuerier, table string, latest uint) (uint, error) {
+	version, dirty, ok, err := schemaVersion(ctx, db, table)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, fmt.Errorf("version %d: %w", version, ErrDirty)
+	}
+
+	if version > latest {
+		return 0, fmt.Errorf("version %d, latest known %d: %w", version, latest, ErrSchemaNewer)
+	}
+
+	return version, nil
+}
+
+// schemaVersion returns version of migrations applied from table, ok is false if none were applied
+func schemaVersion(ctx context.Context, db RowQuerier, table string) (version uint, dirty, ok bool, err error) {
+	var exists bool
+	if err = db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+		return 0, false, false, fmt.Errorf("check migrations table: %w", err)
+	}
+	if !exists {
+		return 0, false, false, nil
+	}
+
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM "+table+" LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, fmt.Errorf("get migration version: %w", err)
+	}
+
+	return version, dirty, true, nil
+}
+
+func (cfg Config) migrationLockTimeout() time.Duration {
+	if cfg.MigrationLockTimeout == 0 {
+		return DefaultMigrationLockTimeout
+	}
+	return cfg.MigrationLockTimeout
+}
+
+// Migrate applies migrations or checks schema according config
+func (cfg Config) Migrate(ctx context.Context, db *sqlx.DB, assets fs.FS) error {
+	switch cfg.Migrations {
+	case MigrationsAuto, "":
+		return cfg.EnsureDB(ctx, assets)
+	case MigrationsCheck:
+		if err := CheckSchema(ctx, db, assets, SchemaMigrationsTable); err != nil {
+			return err
+		}
+		if extra := cfg.ExtraMigrationAssets(); extra != nil {
+			if err := CheckSchema(ctx, db, extra, ExtraMigrationsTable); err != nil {
+				return fmt.Errorf("extra migrations: %w", err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrations mode %q", cfg.Migrations)
+	}
+}
+
+// migrationExt - extension of migration scripts
+const migrationExt = ".psql"
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// CreateMigration creates empty up and down scripts in dir numbered after the last one.
+// Scripts are embedded into binary on the next build.
+func CreateMigration(dir, name string) (up, down string, err error) {
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("name %q: only lowercase letters, digits and _ are allowed", name)
+	}
+
+	version, err := LatestVersion(os.DirFS(dir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", "", err
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%d_%s", version+1, name))
+	up, down = base+".up"+migrationExt, base+".down"+migrationExt
+
+	for _, p := range []string{up, down} {
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return "", "", err
+		}
+		if err = f.Close(); err != nil {
+			return "", "", err
+		}
+	}
+
+	return up, down, nil
+}
+
+// Source - golang-migrate source driver reading migrations from file system root
 type Source struct {
-	Assets     http.FileSystem
-	opened     http.File
+	Assets     fs.FS
 	migrations *source.Migrations
 }
 
 func (s Source) Close() error {
-	return s.opened.Close()
+	return nil
 }
 
 var ErrAppend = errors.New("append to migrations")
 
 func (s Source) Open(url string) (source.Driver, error) {
 	ns := s
-	var err error
-	ns.opened, err = s.Assets.Open("/")
-	if err != nil {
-		return nil, err
-	}
-	files, err := ns.opened.Readdir(-1)
+	files, err := fs.ReadDir(s.Assets, ".")
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +346,7 @@ func (s Source) Open(url string) (source.Driver, error) {
 				continue
 			}
 			if !ns.migrations.Append(m) {
-				return nil, ErrAppend
+				return nil, fmt.Errorf("%s: duplicate version %d: %w", f.Name(), m.Version, ErrAppend)
 			}
 		}
 	}
@@ -96,7 +378,7 @@ func (s Source) Next(version uint) (nextVersion uint, err error) {
 
 func (s Source) ReadUp(version uint) (r io.ReadCloser, identifier string, err error) {
 	if m, ok := s.migrations.Up(version); ok {
-		r, err := s.Assets.Open(path.Join("/", m.Raw))
+		r, err := s.Assets.Open(m.Raw)
 		if err != nil {
 			return nil, "", err
 		}
@@ -107,7 +389,7 @@ func (s Source) ReadUp(version uint) (r io.ReadCloser, identifier string, err er
 
 func (s Source) ReadDown(version uint) (r io.ReadCloser, identifier string, err error) {
 	if m, ok := s.migrations.Down(version); ok {
-		r, err := s.Assets.Open(path.Join("/", m.Raw))
+		r, err := s.Assets.Open(m.Raw)
 		if err != nil {
 			return nil, "", err
 		}
@@ -115,3 +397,83 @@ func (s Source) ReadDown(version uint) (r io.ReadCloser, identifier string, err
 	}
 	return nil, "", &os.PathError{Op: fmt.Sprintf("read version %v", version), Path: "/", Err: os.ErrNotExist}
 }
+
+// ErrDirty - last migration failed and database needs manual fix
+var ErrDirty = errors.New("database is dirty")
+
+// migrationVersions returns versions of all migrations in assets in ascending order
+func migrationVersions(assets fs.FS) ([]uint, error) {
+	d, err := Source{Assets: assets}.Open("")
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	version, err := d.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []uint{version}
+	for {
+		next, err := d.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, next)
+		version = next
+	}
+}
+
+// LatestVersion returns version of the last migration in assets
+func LatestVersion(assets fs.FS) (uint, error) {
+	d, err := Source{Assets: assets}.Open("")
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+
+	version, err := d.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := d.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// CheckVersion checks that schema migrations are applied at least up to expected version and are not dirty
+func CheckVersion(ctx context.Context, db *sqlx.DB, expected uint) error {
+	version, dirty, ok, err := schemaVersion(ctx, db, SchemaMigrationsTable)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no migrations applied, expected version %d", expected)
+	}
+
+	if dirty {
+		return fmt.Errorf("version %d: %w", version, ErrDirty)
+	}
+
+	// newer schema is normal during rollout, when new pods have already migrated it
+	if version < expected {
+		return fmt.Errorf("migration version %d, expected at least %d", version, expected)
+	}
+
+	return nil
+}
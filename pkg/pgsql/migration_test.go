@@ -0,0 +1,309 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	up, down, err := CreateMigration(dir, "init")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if up != filepath.Join(dir, "1_init.up.psql") || down != filepath.Join(dir, "1_init.down.psql") {
+		t.Errorf("unexpected files: %s, %s", up, down)
+	}
+
+	if up, _, err = CreateMigration(dir, "users"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if up != filepath.Join(dir, "2_users.up.psql") {
+		t.Errorf("unexpected file: %s", up)
+	}
+
+	version, err := LatestVersion(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if version != 2 {
+		t.Errorf("expected version 2, got: %d", version)
+	}
+
+	if _, _, err = CreateMigration(dir, "Drop Table"); err == nil {
+		t.Errorf("expected error for invalid name")
+	}
+}
+
+func TestSources(t *testing.T) {
+	schema := fstest.MapFS{
+		"1_init.up.psql":   {Data: []byte("CREATE TABLE t (id int);")},
+		"1_init.down.psql": {Data: []byte("DROP TABLE t;")},
+		"2_more.up.psql":   {Data: []byte("ALTER TABLE t ADD v int;")},
+	}
+	seed := fstest.MapFS{
+		"1000_seed.up.psql": {Data: []byte("INSERT INTO t VALUES (1, 1);")},
+	}
+
+	d, err := Source{Assets: Sources(schema, seed)}.Open("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var versions []uint
+	for v, err := d.First(); err == nil; v, err = d.Next(v) {
+		versions = append(versions, v)
+	}
+	if !reflect.DeepEqual(versions, []uint{1, 2, 1000}) {
+		t.Errorf("unexpected versions: %v", versions)
+	}
+
+	r, _, err := d.ReadUp(1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	if body, _ := ioutil.ReadAll(r); string(body) != "INSERT INTO t VALUES (1, 1);" {
+		t.Errorf("unexpected script: %s", body)
+	}
+
+	clash := fstest.MapFS{"2_other.up.psql": {}}
+	if _, err = (Source{Assets: Sources(schema, clash)}).Open(""); !errors.Is(err, ErrAppend) {
+		t.Errorf("expected ErrAppend, got: %v", err)
+	}
+
+	if _, err = (Source{Assets: Sources(schema, schema)}).Open(""); !errors.Is(err, ErrDuplicateMigration) {
+		t.Errorf("expected ErrDuplicateMigration, got: %v", err)
+	}
+}
+
+func TestExtraMigrationAssets(t *testing.T) {
+	if (Config{}).ExtraMigrationAssets() != nil {
+		t.Errorf("expected no extra assets")
+	}
+
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// extra versions have own sequence and may be lower than schema ones
+	if _, _, err = CreateMigration(dir, "test_data"); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := LatestVersion(Config{ExtraMigrations: []string{dir}}.ExtraMigrationAssets())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("unexpected version: %d", version)
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version uint
+		dirty   bool
+		ok      bool
+	}{
+		{version: 3, ok: true},
+		{version: 4, ok: true}, // newer pods already migrated during rollout
+		{version: 2},
+		{version: 3, dirty: true},
+	}
+
+	for _, tt := range tests {
+		db, mock := newMockDB(t)
+		expectMigrationsTable(mock, true)
+		mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(tt.version, tt.dirty))
+
+		err := CheckVersion(context.Background(), db, 3)
+		if (err == nil) != tt.ok {
+			t.Errorf("version %d, dirty %v: unexpected error: %v", tt.version, tt.dirty, err)
+		}
+		if tt.dirty && !errors.Is(err, ErrDirty) {
+			t.Errorf("expected error: %v, got: %v", ErrDirty, err)
+		}
+
+		db.Close()
+	}
+}
+
+func expectMigrationsTable(mock sqlmock.Sqlmock, exists bool) {
+	mock.ExpectQuery("SELECT to_regclass").WithArgs("schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+}
+
+func TestCheckSchema(t *testing.T) {
+	assets := fstest.MapFS{
+		"1_init.up.psql":   {},
+		"1_init.down.psql": {},
+		"2_next.up.psql":   {},
+	}
+
+	// nothing is created in empty database
+	db, mock := newMockDB(t)
+	expectMigrationsTable(mock, false)
+	if err := CheckSchema(context.Background(), db, assets, SchemaMigrationsTable); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	db.Close()
+
+	tests := []struct {
+		version  uint
+		dirty    bool
+		expected error
+	}{
+		{version: 1},
+		{version: 2},
+		{version: 3, expected: ErrSchemaNewer},
+		{version: 1, dirty: true, expected: ErrDirty},
+	}
+
+	for _, tt := range tests {
+		db, mock := newMockDB(t)
+		expectMigrationsTable(mock, true)
+		mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(tt.version, tt.dirty))
+
+		err := CheckSchema(context.Background(), db, assets, SchemaMigrationsTable)
+		if !errors.Is(err, tt.expected) || (tt.expected == nil && err != nil) {
+			t.Errorf("version %d, dirty %v: expected error: %v, got: %v", tt.version, tt.dirty, tt.expected, err)
+		}
+
+		db.Close()
+	}
+}
+
+func TestEnsureLocked_LimitedPool(t *testing.T) {
+	assets := fstest.MapFS{
+		"1_init.up.psql": {},
+		"2_next.up.psql": {},
+	}
+
+	db, mock := newMockDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(migratorMaxOpenConns)
+
+	// connection pinned by migrate driver
+	pinned, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pinned.Close()
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(MigrationLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	expectMigrationsTable(mock, true)
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(MigrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// waiting for third connection fails by deadline instead of hanging
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var applied []uint
+	sets := []migrationSet{{
+		assets: assets,
+		table:  SchemaMigrationsTable,
+		migrate: func(version uint) error {
+			applied = append(applied, version)
+			return nil
+		},
+	}}
+	if err = ensureLocked(ctx, db, time.Second, sets); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(applied, []uint{2}) {
+		t.Errorf("unexpected applied versions: %v", applied)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEnsureLocked_Order(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(MigrationLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	expectMigrationsTable(mock, true)
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+	mock.ExpectQuery("SELECT to_regclass").WithArgs(ExtraMigrationsTable).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(MigrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	type step struct {
+		table   string
+		version uint
+	}
+	var applied []step
+	set := func(table string, assets fs.FS) migrationSet {
+		return migrationSet{assets: assets, table: table, migrate: func(version uint) error {
+			applied = append(applied, step{table, version})
+			return nil
+		}}
+	}
+
+	sets := []migrationSet{
+		set(SchemaMigrationsTable, fstest.MapFS{
+			"1_init.up.psql":  {},
+			"2_users.up.psql": {},
+			"4_index.up.psql": {},
+		}),
+		set(ExtraMigrationsTable, fstest.MapFS{
+			"1_data.up.psql":  {},
+			"3_users.up.psql": {},
+			"4_more.up.psql":  {},
+		}),
+	}
+
+	if err := ensureLocked(context.Background(), db, time.Second, sets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// pending migrations of both sets in version order, schema one first on equal versions
+	expected := []step{
+		{ExtraMigrationsTable, 1},
+		{SchemaMigrationsTable, 2},
+		{ExtraMigrationsTable, 3},
+		{SchemaMigrationsTable, 4},
+		{ExtraMigrationsTable, 4},
+	}
+	if !reflect.DeepEqual(applied, expected) {
+		t.Errorf("unexpected order: %v", applied)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,57 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// backends of repositories
+	BackendSQLX = "sqlx"
+	BackendPgx  = "pgx"
+)
+
+// CreatePool returns native pgx pool according config. Like CreateDB,
+// it does not connect until the first query.
+func (cfg Config) CreatePool(ctx context.Context) (*pgxpool.Pool, error) {
+	pcfg, err := pgxpool.ParseConfig(cfg.Connection)
+	if err != nil {
+		// error may contain the connection string itself
+		return nil, fmt.Errorf("invalid connection string")
+	}
+
+	pcfg.LazyConnect = true
+
+	if cfg.MaxOpenConn != 0 {
+		pcfg.MaxConns = int32(cfg.MaxOpenConn)
+	}
+
+	if cfg.MinConns != 0 {
+		pcfg.MinConns = cfg.MinConns
+	}
+
+	if cfg.MaxConnLifetime != 0 {
+		pcfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+
+	if cfg.HealthCheckPeriod != 0 {
+		pcfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	return pgxpool.ConnectConfig(ctx, pcfg)
+}
+
+// PingPool checks pool connectivity, it is used as health check
+func PingPool(pool *pgxpool.Pool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Release()
+
+		return conn.Conn().Ping(ctx)
+	}
+}
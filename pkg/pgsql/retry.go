@@ -0,0 +1,144 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultRetryMaxRetries = 3
+	DefaultRetryBaseDelay  = 50 * time.Millisecond
+	DefaultRetryMaxDelay   = time.Second
+)
+
+// RetryConfig is a configuration for retries of idempotent calls
+type RetryConfig struct {
+	MaxRetries int           `yaml:"max_retries" json:"max_retries" toml:"max_retries"`
+	BaseDelay  time.Duration `yaml:"base_delay" json:"base_delay" toml:"base_delay"`
+	MaxDelay   time.Duration `yaml:"max_delay" json:"max_delay" toml:"max_delay"`
+}
+
+// IsConnectionError reports whether err is caused by unavailable database
+// rather than by query itself, so that idempotent call may be repeated
+func IsConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var retry interface{ SafeToRetry() bool }
+	if errors.As(err, &retry) && retry.SafeToRetry() {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// class 08 - connection exception, 57P01-57P03 - server shutdown or starting
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	return false
+}
+
+// Retrier repeats idempotent calls failed by connection errors with exponential
+// backoff and full jitter, calls fail fast while breaker is open
+type Retrier struct {
+	breaker    *Breaker
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// NewRetrier returns retrier according config, breaker may be nil
+func NewRetrier(cfg RetryConfig, breaker *Breaker) *Retrier {
+	r := &Retrier{
+		breaker:    breaker,
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.BaseDelay,
+		maxDelay:   cfg.MaxDelay,
+	}
+
+	if r.maxRetries == 0 {
+		r.maxRetries = DefaultRetryMaxRetries
+	}
+
+	if r.baseDelay == 0 {
+		r.baseDelay = DefaultRetryBaseDelay
+	}
+
+	if r.maxDelay == 0 {
+		r.maxDelay = DefaultRetryMaxDelay
+	}
+
+	return r
+}
+
+// Do runs fn until it succeeds, fails not by connection error or retries are exhausted
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		if r.breaker != nil {
+			if err := r.breaker.Allow(); err != nil {
+				return err
+			}
+		}
+
+		err := fn(ctx)
+		if ctx.Err() != nil {
+			// result of canceled call says nothing about database
+			return err
+		}
+
+		if !IsConnectionError(err) {
+			if r.breaker != nil {
+				r.breaker.Success()
+			}
+			return err
+		}
+
+		if r.breaker != nil {
+			r.breaker.Failure()
+		}
+
+		if attempt >= r.maxRetries {
+			return err
+		}
+
+		delay := r.baseDelay << attempt
+		if delay <= 0 || delay > r.maxDelay {
+			delay = r.maxDelay
+		}
+		delay = time.Duration(rand.Int63n(int64(delay) + 1)) // nolint:gosec // jitter
+
+		logger.GetLogger(ctx).WithFields(logrus.Fields{
+			"actor":   "pgsql",
+			"func":    "Retry",
+			"attempt": attempt + 1,
+			"delay":   delay,
+		}).WithError(err).Warn("retry on connection error")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
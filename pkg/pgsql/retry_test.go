@@ -0,0 +1,107 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("syntax error"), false},
+		{fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{&pgconn.PgError{Code: "08006"}, true},
+		{&pgconn.PgError{Code: "57P01"}, true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{context.DeadlineExceeded, false},
+		{ErrCircuitOpen, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsConnectionError(tt.err); got != tt.expected {
+			t.Errorf("IsConnectionError(%v) = %v, want %v", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestRetrier(t *testing.T) {
+	r := NewRetrier(RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, nil)
+
+	calls := 0
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("expected success on the third call, got: %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	queryErr := errors.New("syntax error")
+	err = r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return queryErr
+	})
+	if !errors.Is(err, queryErr) || calls != 1 {
+		t.Errorf("query errors must not be retried, got: %v after %d calls", err, calls)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	b := BreakerConfig{Threshold: 2, OpenTimeout: time.Second}.CreateBreaker()
+	b.now = func() time.Time { return now }
+
+	r := NewRetrier(RetryConfig{MaxRetries: -1}, b)
+	down := func(ctx context.Context) error { return driver.ErrBadConn }
+	up := func(ctx context.Context) error { return nil }
+
+	for i := 0; i < 2; i++ {
+		if err := r.Do(context.Background(), down); !errors.Is(err, driver.ErrBadConn) {
+			t.Errorf("expected connection error, got: %v", err)
+		}
+	}
+
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected open circuit after threshold, got: %v", err)
+	}
+
+	calls := 0
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) || calls != 0 {
+		t.Errorf("expected fail fast, got: %v after %d calls", err, calls)
+	}
+
+	// probe fails and opens circuit again
+	now = now.Add(time.Second)
+	if err = r.Do(context.Background(), down); !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("expected probe call, got: %v", err)
+	}
+	if err = r.Do(context.Background(), up); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected open circuit after failed probe, got: %v", err)
+	}
+
+	// probe succeeds and closes circuit
+	now = now.Add(time.Second)
+	if err = r.Do(context.Background(), up); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err = b.Allow(); err != nil {
+		t.Errorf("expected closed circuit, got: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package pgsql
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// ErrDuplicateMigration - the same script is present in several sources
+var ErrDuplicateMigration = errors.New("duplicate migration file")
+
+// multiFS - union of migration sources, scripts of all sources form one ordered set
+type multiFS []fs.FS
+
+// Sources combines several migration sources into one, versions are shared among them
+func Sources(sources ...fs.FS) fs.FS {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return multiFS(sources)
+}
+
+func (m multiFS) Open(name string) (fs.File, error) {
+	for _, fsys := range m {
+		f, err := fsys.Open(name)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return f, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m multiFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var (
+		entries []fs.DirEntry
+		seen    = make(map[string]bool)
+	)
+
+	for _, fsys := range m {
+		list, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range list {
+			if seen[e.Name()] {
+				return nil, fmt.Errorf("%s: %w", e.Name(), ErrDuplicateMigration)
+			}
+			seen[e.Name()] = true
+			entries = append(entries, e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// ExtraMigrationAssets returns extra migration directories from config combined
+// into one source, nil if there are none
+func (cfg Config) ExtraMigrationAssets() fs.FS {
+	if len(cfg.ExtraMigrations) == 0 {
+		return nil
+	}
+
+	sources := make([]fs.FS, 0, len(cfg.ExtraMigrations))
+	for _, dir := range cfg.ExtraMigrations {
+		sources = append(sources, os.DirFS(dir))
+	}
+	return Sources(sources...)
+}
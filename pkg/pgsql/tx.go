@@ -0,0 +1,164 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTxMaxRetries - retries of transactions failed with serialization failure or deadlock
+	DefaultTxMaxRetries = 3
+
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+
+	// txRetryDelay - base of exponential delay between retries
+	txRetryDelay = 10 * time.Millisecond
+)
+
+// isolation levels names in config
+var isolationLevels = map[string]sql.IsolationLevel{
+	"":                 sql.LevelDefault,
+	"read_committed":   sql.LevelReadCommitted,
+	"repeatable_read":  sql.LevelRepeatableRead,
+	"serializable":     sql.LevelSerializable,
+	"read_uncommitted": sql.LevelReadUncommitted,
+}
+
+type txKey struct{}
+
+// Querier - part of *sqlx.DB and *sqlx.Tx used by repositories
+type Querier interface {
+	sqlx.QueryerContext
+	sqlx.ExecerContext
+}
+
+// Conn returns transaction started by TxManager if ctx has one, db otherwise.
+// Repositories use it to take part in transactions of usecases transparently.
+func Conn(ctx context.Context, db *sqlx.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// ParseIsolation returns isolation level by name (read_committed|repeatable_read|serializable),
+// empty name means database default
+func ParseIsolation(name string) (sql.IsolationLevel, error) {
+	level, ok := isolationLevels[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown isolation level %q", name)
+	}
+	return level, nil
+}
+
+// IsRetryable reports whether transaction failed because of concurrent transactions
+// and can be retried: serialization failure or deadlock
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+// TxManager runs functions in transactions passed through context
+type TxManager struct {
+	db         *sqlx.DB
+	isolation  sql.IsolationLevel
+	maxRetries int
+}
+
+// NewTxManager returns transaction manager, negative maxRetries disables retries
+func NewTxManager(db *sqlx.DB, isolation sql.IsolationLevel, maxRetries int) *TxManager {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return &TxManager{db: db, isolation: isolation, maxRetries: maxRetries}
+}
+
+// CreateTxManager returns transaction manager according config
+func (cfg Config) CreateTxManager(db *sqlx.DB) (*TxManager, error) {
+	isolation, err := ParseIsolation(cfg.TxIsolation)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := cfg.TxMaxRetries
+	if retries == 0 {
+		retries = DefaultTxMaxRetries
+	}
+
+	return NewTxManager(db, isolation, retries), nil
+}
+
+// Do runs fn in transaction with default isolation level
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.DoWithIsolation(ctx, m.isolation, fn)
+}
+
+// DoWithIsolation runs fn in transaction, which is committed if fn returns nil and
+// rolled back otherwise. Transaction failed with serialization failure or deadlock is
+// retried, so fn must not have side effects outside the database.
+// Nested calls join the outer transaction and its isolation level.
+func (m *TxManager) DoWithIsolation(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, level, fn)
+		if err == nil || !IsRetryable(err) || attempt >= m.maxRetries {
+			return err
+		}
+
+		logger.GetLogger(ctx).WithFields(logrus.Fields{
+			"actor":   "pgsql",
+			"func":    "DoWithIsolation",
+			"attempt": attempt + 1,
+		}).WithError(err).Warn("retry transaction")
+
+		// jitter spreads retries of transactions conflicting with each other
+		delay := txRetryDelay << attempt
+		delay += time.Duration(rand.Int63n(int64(delay))) // nolint:gosec // jitter
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, level sql.IsolationLevel, fn func(ctx context.Context) error) (err error) {
+	tx, err := m.db.BeginTxx(ctx, &sql.TxOptions{Isolation: level})
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			logger.GetLogger(ctx).WithError(rerr).WithField("actor", "pgsql").Error("rollback")
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+)
+
+func TestTxManager_Commit(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m := NewTxManager(db, sql.LevelDefault, 0)
+	err := m.Do(context.Background(), func(ctx context.Context) error {
+		if Conn(ctx, db) == Querier(db) {
+			t.Errorf("expected transaction in context")
+		}
+		if _, err := Conn(ctx, db).ExecContext(ctx, "UPDATE a"); err != nil {
+			return err
+		}
+		// nested call joins transaction
+		return m.Do(ctx, func(ctx context.Context) error {
+			_, err := Conn(ctx, db).ExecContext(ctx, "UPDATE b")
+			return err
+		})
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestTxManager_Rollback(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	errFailed := errors.New("failed")
+	err := NewTxManager(db, sql.LevelDefault, 3).Do(context.Background(), func(ctx context.Context) error {
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("expected fn error, got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestTxManager_Retry(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	serialization := &pgconn.PgError{Code: serializationFailure}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnError(serialization)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit().WillReturnError(&pgconn.PgError{Code: deadlockDetected})
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	calls := 0
+	err := NewTxManager(db, sql.LevelSerializable, 2).Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if _, err := Conn(ctx, db).ExecContext(ctx, "UPDATE a"); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got: %d", calls)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	// retries are exhausted
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnError(serialization)
+	mock.ExpectRollback()
+
+	err = NewTxManager(db, sql.LevelSerializable, -1).Do(context.Background(), func(ctx context.Context) error {
+		_, err := Conn(ctx, db).ExecContext(ctx, "UPDATE a")
+		return err
+	})
+	if !IsRetryable(err) {
+		t.Errorf("expected serialization failure, got: %v", err)
+	}
+}
+
+func TestParseIsolation(t *testing.T) {
+	if level, err := ParseIsolation("serializable"); err != nil || level != sql.LevelSerializable {
+		t.Errorf("unexpected result: %v, %v", level, err)
+	}
+	if _, err := ParseIsolation("snapshot"); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
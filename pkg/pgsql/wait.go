@@ -0,0 +1,69 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultStartupTimeout  = 30 * time.Second
+	DefaultStartupMaxDelay = 5 * time.Second
+
+	// startupInitialDelay - delay after the first failed ping, it doubles after each attempt
+	startupInitialDelay = 100 * time.Millisecond
+)
+
+// Wait pings database with exponential backoff until it answers or StartupTimeout expires,
+// so that service started together with database does not crash
+func (cfg Config) Wait(ctx context.Context, ping func(ctx context.Context) error) error {
+	timeout := cfg.StartupTimeout
+	if timeout == 0 {
+		timeout = DefaultStartupTimeout
+	}
+
+	maxDelay := cfg.StartupMaxDelay
+	if maxDelay == 0 {
+		maxDelay = DefaultStartupMaxDelay
+	}
+
+	log := logger.GetLogger(ctx).WithFields(logrus.Fields{
+		"actor": "pgsql",
+		"func":  "Wait",
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := startupInitialDelay
+	for attempt := 1; ; attempt++ {
+		// single attempt must not take the whole deadline, e.g. on unreachable host
+		pctx, pcancel := context.WithTimeout(ctx, maxDelay)
+		err := ping(pctx)
+		pcancel()
+		if err == nil {
+			if attempt > 1 {
+				log.WithField("attempt", attempt).Info("database is available")
+			}
+			return nil
+		}
+
+		log.WithError(err).WithFields(logrus.Fields{
+			"attempt": attempt,
+			"delay":   delay,
+		}).Warn("database is not available")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not available after %v: %w", timeout, err)
+		case <-time.After(delay):
+		}
+
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	cfg := Config{StartupTimeout: time.Second, StartupMaxDelay: 200 * time.Millisecond}
+
+	calls := 0
+	err := cfg.Wait(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 pings, got: %d", calls)
+	}
+}
+
+func TestWait_Timeout(t *testing.T) {
+	cfg := Config{StartupTimeout: 300 * time.Millisecond, StartupMaxDelay: 100 * time.Millisecond}
+	errRefused := errors.New("connection refused")
+
+	start := time.Now()
+	err := cfg.Wait(context.Background(), func(ctx context.Context) error {
+		return errRefused
+	})
+	if !errors.Is(err, errRefused) {
+		t.Errorf("expected ping error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait exceeded timeout: %v", elapsed)
+	}
+}
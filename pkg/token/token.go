@@ -0,0 +1,160 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/moguchev/service/pkg/auth"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+
+	// MinSecretLen - min length of HS256 secret, shorter ones can be brute forced
+	MinSecretLen = 32
+)
+
+var (
+	// ErrNoSecret - signing secret is not configured
+	ErrNoSecret = errors.New("token secret is empty")
+	// ErrWeakSecret - signing secret is too short or a known placeholder
+	ErrWeakSecret = errors.New("token secret is weak")
+)
+
+// placeholders - secrets from examples, which must never be used
+var placeholders = []string{"change-me", "changeme", "change_me", "placeholder", "your-secret"}
+
+// Config is a configuration for access tokens, it must be the same for
+// the authorization service issuing tokens and services verifying them
+type Config struct {
+	Secret     string        `yaml:"secret" json:"secret" toml:"secret"`
+	Issuer     string        `yaml:"issuer" json:"issuer" toml:"issuer"`
+	AccessTTL  time.Duration `yaml:"access_ttl" json:"access_ttl" toml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl" json:"refresh_ttl" toml:"refresh_ttl"`
+}
+
+// Claims - access token payload
+type Claims struct {
+	jwt.RegisteredClaims
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// Manager issues and verifies HS256 signed access tokens
+type Manager struct {
+	secret     []byte
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// Validate rejects empty, short and placeholder secrets
+func (cfg Config) Validate() error {
+	if cfg.Secret == "" {
+		return ErrNoSecret
+	}
+
+	lower := strings.ToLower(cfg.Secret)
+	for _, p := range placeholders {
+		if strings.Contains(lower, p) {
+			return fmt.Errorf("%w: contains placeholder %q", ErrWeakSecret, p)
+		}
+	}
+
+	if len(cfg.Secret) < MinSecretLen {
+		return fmt.Errorf("%w: must be at least %d bytes, got %d", ErrWeakSecret, MinSecretLen, len(cfg.Secret))
+	}
+
+	return nil
+}
+
+// CreateManager returns token manager according config
+func (cfg Config) CreateManager() (*Manager, error) {
+	if cfg.Secret == "" {
+		return nil, ErrNoSecret
+	}
+
+	m := &Manager{
+		secret:     []byte(cfg.Secret),
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		now:        time.Now,
+	}
+
+	if m.accessTTL == 0 {
+		m.accessTTL = defaultAccessTTL
+	}
+
+	if m.refreshTTL == 0 {
+		m.refreshTTL = defaultRefreshTTL
+	}
+
+	return m, nil
+}
+
+// RefreshTTL returns lifetime of refresh tokens
+func (m *Manager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
+
+// Issue returns signed access token for subject and its expiration time
+func (m *Manager) Issue(subject string, scopes []string) (string, time.Time, error) {
+	now := m.now()
+	expires := now.Add(m.accessTTL)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+		Scopes: scopes,
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign: %w", err)
+	}
+
+	return signed, expires, nil
+}
+
+// Verify parses token and checks its signature, expiration and issuer
+func (m *Manager) Verify(token string) (Claims, error) {
+	claims := Claims{}
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	_, err := parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", auth.ErrInvalidCredentials, err)
+	}
+
+	if m.issuer != "" && !claims.VerifyIssuer(m.issuer, true) {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer %q", auth.ErrInvalidCredentials, claims.Issuer)
+	}
+
+	return claims, nil
+}
+
+// AuthenticateToken implements middleware.TokenAuthenticator
+func (m *Manager) AuthenticateToken(ctx context.Context, token string) (auth.Principal, error) {
+	claims, err := m.Verify(token)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	return auth.Principal{
+		Subject: claims.Subject,
+		Method:  auth.MethodJWT,
+		Scopes:  claims.Scopes,
+	}, nil
+}
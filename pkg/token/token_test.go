@@ -0,0 +1,82 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/moguchev/service/pkg/auth"
+)
+
+func TestIssueVerify(t *testing.T) {
+	m, err := Config{Secret: "secret", Issuer: "auth"}.CreateManager()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed, expires, err := m.Issue("42", []string{"employees:read"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := time.Until(expires); d <= 0 || d > defaultAccessTTL {
+		t.Errorf("unexpected expiration: %v", expires)
+	}
+
+	p, err := m.AuthenticateToken(context.Background(), signed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Subject != "42" || p.Method != auth.MethodJWT || !p.HasScope("employees:read") {
+		t.Errorf("unexpected principal: %v", p)
+	}
+}
+
+func TestVerify_Invalid(t *testing.T) {
+	m, _ := Config{Secret: "secret", Issuer: "auth"}.CreateManager()
+	other, _ := Config{Secret: "other", Issuer: "auth"}.CreateManager()
+	foreign, _ := Config{Secret: "secret", Issuer: "foreign"}.CreateManager()
+	expired, _ := Config{Secret: "secret", Issuer: "auth"}.CreateManager()
+	expired.now = func() time.Time { return time.Now().Add(-time.Hour) }
+
+	tokens := map[string]*Manager{"signature": other, "issuer": foreign, "expired": expired}
+	for name, issuer := range tokens {
+		signed, _, err := issuer.Issue("42", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = m.Verify(signed); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%s: expected error: %v, got: %v", name, auth.ErrInvalidCredentials, err)
+		}
+	}
+
+	if _, err := m.Verify("not a token"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected error: %v, got: %v", auth.ErrInvalidCredentials, err)
+	}
+}
+
+func TestCreateManager_NoSecret(t *testing.T) {
+	if _, err := (Config{}).CreateManager(); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("expected error: %v, got: %v", ErrNoSecret, err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]error{
+		"":          ErrNoSecret,
+		"short":     ErrWeakSecret,
+		"change-me": ErrWeakSecret,
+		"CHANGE-ME-CHANGE-ME-CHANGE-ME-CHANGE-ME": ErrWeakSecret,
+		"0123456789abcdef0123456789abcdef":        nil,
+	}
+
+	for secret, expected := range tests {
+		err := Config{Secret: secret}.Validate()
+		if !errors.Is(err, expected) || (expected == nil && err != nil) {
+			t.Errorf("%q: expected error: %v, got: %v", secret, expected, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"go.opencensus.io/trace"
+)
+
+// writerExporter writes spans as JSON lines, used for local development
+type writerExporter struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+}
+
+func newWriterExporter(w io.WriteCloser) *writerExporter {
+	return &writerExporter{w: w, enc: json.NewEncoder(w)}
+}
+
+type jsonSpan struct {
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id"`
+	ParentID   string                 `json:"parent_id,omitempty"`
+	Name       string                 `json:"name"`
+	Kind       string                 `json:"kind"`
+	Start      string                 `json:"start"`
+	DurationMS float64                `json:"duration_ms"`
+	Status     int32                  `json:"status"`
+	Message    string                 `json:"message,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+func (e *writerExporter) ExportSpan(s *trace.SpanData) {
+	js := jsonSpan{
+		TraceID:    s.TraceID.String(),
+		SpanID:     s.SpanID.String(),
+		Name:       s.Name,
+		Kind:       spanKind(s.SpanKind),
+		Start:      s.StartTime.Format("2006-01-02T15:04:05.000000Z07:00"),
+		DurationMS: float64(s.EndTime.Sub(s.StartTime).Microseconds()) / 1000,
+		Status:     s.Code,
+		Message:    s.Message,
+		Attributes: s.Attributes,
+	}
+
+	if s.ParentSpanID != (trace.SpanID{}) {
+		js.ParentID = s.ParentSpanID.String()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_ = e.enc.Encode(js)
+}
+
+func (e *writerExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.w.Close()
+}
+
+func spanKind(kind int) string {
+	switch kind {
+	case trace.SpanKindServer:
+		return "server"
+	case trace.SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/moguchev/service/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// TraceIDField - logger field with trace id
+	TraceIDField = "trace_id"
+	// routeAttribute - mux route template, not defined by ochttp
+	routeAttribute = "http.route"
+)
+
+var format = &tracecontext.HTTPFormat{}
+
+// Middleware - starts server span named by mux route template, continuing
+// the trace from W3C traceparent header if present
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := utils.RouteTemplate(r, utils.UnmatchedRoute)
+		name := r.Method + " " + route
+
+		var (
+			ctx  context.Context
+			span *trace.Span
+		)
+
+		if parent, ok := format.SpanContextFromRequest(r); ok {
+			ctx, span = trace.StartSpanWithRemoteParent(r.Context(), name, parent,
+				trace.WithSpanKind(trace.SpanKindServer))
+		} else {
+			ctx, span = trace.StartSpan(r.Context(), name, trace.WithSpanKind(trace.SpanKindServer))
+		}
+		defer span.End()
+
+		span.AddAttributes(
+			trace.StringAttribute(ochttp.MethodAttribute, r.Method),
+			trace.StringAttribute(ochttp.PathAttribute, r.URL.Path),
+			trace.StringAttribute(routeAttribute, route),
+			trace.StringAttribute(ochttp.UserAgentAttribute, r.UserAgent()),
+		)
+
+		if sc := span.SpanContext(); sc.IsSampled() {
+			ctx = logger.WithFields(ctx, logrus.Fields{TraceIDField: sc.TraceID.String()})
+		}
+
+		rw := utils.NewResponseWriter(w)
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.AddAttributes(trace.Int64Attribute(ochttp.StatusCodeAttribute, int64(rw.Status())))
+		span.SetStatus(ochttp.TraceStatus(rw.Status(), http.StatusText(rw.Status())))
+	})
+}
+
+// StartSpan starts internal span, e.g. for usecase calls
+func StartSpan(ctx context.Context, name string) (context.Context, *trace.Span) {
+	return trace.StartSpan(ctx, name)
+}
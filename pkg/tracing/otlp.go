@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+// otlpShutdownTimeout - time given to send the last spans on close
+const otlpShutdownTimeout = 5 * time.Second
+
+// otlpSampler - sampler of OpenTelemetry SDK, spans of bridged OpenCensus API
+// are sampled by it instead of OpenCensus sampler
+var otlpSampler = &rateSampler{}
+
+// rateSampler samples fraction of root spans, rate can be changed while serving
+type rateSampler struct {
+	sampler atomic.Value // sdktrace.Sampler
+}
+
+func (s *rateSampler) setRate(rate float64) {
+	s.sampler.Store(sdktrace.TraceIDRatioBased(rate))
+}
+
+func (s *rateSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.sampler.Load().(sdktrace.Sampler).ShouldSample(p)
+}
+
+func (s *rateSampler) Description() string {
+	return "RateSampler"
+}
+
+// initOTLP sends spans to OTLP/HTTP endpoint, e.g. http://collector:4318/v1/traces.
+// OpenCensus tracer is replaced by bridge to OpenTelemetry SDK, so that spans of
+// middleware, usecases and ocsql are exported by OpenTelemetry exporter.
+func (cfg Config) initOTLP(log logrus.FieldLogger) (Flusher, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("endpoint %q: must be url like http://collector:4318/v1/traces", cfg.Endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if u.Path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	exp, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.WithError(err).Warn("export spans")
+	}))
+
+	otlpSampler.setRate(cfg.SampleRate)
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp,
+			sdktrace.WithBatchTimeout(cfg.FlushInterval),
+			sdktrace.WithMaxExportBatchSize(cfg.BatchSize),
+		),
+		sdktrace.WithSampler(sdktrace.ParentBased(otlpSampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(cfg.ServiceName),
+		)),
+	)
+
+	prev := trace.DefaultTracer
+	trace.DefaultTracer = opencensus.NewTracer(tp.Tracer("github.com/moguchev/service/pkg/tracing"))
+
+	return otlpFlusher{tp: tp, prev: prev}, nil
+}
+
+type otlpFlusher struct {
+	tp   *sdktrace.TracerProvider
+	prev trace.Tracer
+}
+
+func (f otlpFlusher) Close() error {
+	trace.DefaultTracer = f.prev
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+	defer cancel()
+
+	return f.tp.Shutdown(ctx)
+}
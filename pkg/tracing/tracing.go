@@ -0,0 +1,194 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	stdlog "log"
+	"os"
+	"strings"
+	"time"
+
+	"contrib.go.opencensus.io/exporter/jaeger"
+	"contrib.go.opencensus.io/exporter/zipkin"
+	"github.com/openzipkin/zipkin-go/model"
+	reporterhttp "github.com/openzipkin/zipkin-go/reporter/http"
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// exporters
+	None   = "none"
+	Stdout = "stdout"
+	File   = "file"
+	// OTLP - OpenTelemetry protocol over HTTP, e.g. to OpenTelemetry Collector
+	OTLP   = "otlp"
+	Jaeger = "jaeger"
+	Zipkin = "zipkin"
+
+	defaultFlushInterval = 5 * time.Second
+	defaultBatchSize     = 512
+)
+
+// Config is a configuration for tracing
+type Config struct {
+	Exporter    string  `yaml:"exporter" json:"exporter" toml:"exporter"` // enum (none|stdout|file|otlp|jaeger|zipkin)
+	ServiceName string  `yaml:"service_name" json:"service_name" toml:"service_name"`
+	SampleRate  float64 `yaml:"sample_rate" json:"sample_rate" toml:"sample_rate"` // fraction of root spans to record, 0 means never
+	// Endpoint - OTLP/HTTP url (http://collector:4318/v1/traces), Jaeger collector url
+	// (http://jaeger:14268/api/traces) or Zipkin url (http://zipkin:9411/api/v2/spans)
+	Endpoint string `yaml:"endpoint" json:"endpoint" toml:"endpoint"`
+	// Path - file for file exporter
+	Path string `yaml:"path" json:"path" toml:"path"`
+	// FlushInterval and BatchSize - batching of otlp and zipkin exporters
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval" toml:"flush_interval"`
+	BatchSize     int           `yaml:"batch_size" json:"batch_size" toml:"batch_size"`
+}
+
+// Flusher sends buffered spans and releases exporter resources
+type Flusher interface {
+	Close() error
+}
+
+type nopFlusher struct{}
+
+func (nopFlusher) Close() error { return nil }
+
+// Validate checks exporter settings
+func (cfg Config) Validate() error {
+	switch cfg.Exporter {
+	case "", None, Stdout:
+	case File:
+		if cfg.Path == "" {
+			return fmt.Errorf("path is required for %s exporter", cfg.Exporter)
+		}
+	case OTLP, Jaeger, Zipkin:
+		if cfg.Endpoint == "" {
+			return fmt.Errorf("endpoint is required for %s exporter", cfg.Exporter)
+		}
+	default:
+		return fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return fmt.Errorf("sample_rate: must be in [0, 1], got %v", cfg.SampleRate)
+	}
+
+	if cfg.FlushInterval < 0 || cfg.BatchSize < 0 {
+		return errors.New("flush_interval and batch_size must not be negative")
+	}
+
+	return nil
+}
+
+// Init registers exporter and sampler globally according config, export errors are
+// logged to log. Returned Flusher must be closed on shutdown to not lose last spans.
+func (cfg Config) Init(log logrus.FieldLogger) (Flusher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Exporter == "" || cfg.Exporter == None {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return nopFlusher{}, nil
+	}
+
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+
+	log = log.WithFields(logrus.Fields{"actor": "tracing", "exporter": cfg.Exporter})
+
+	var (
+		exp    trace.Exporter
+		closer func() error
+	)
+
+	switch cfg.Exporter {
+	case Stdout:
+		w := newWriterExporter(nopCloser{os.Stdout})
+		exp, closer = w, w.Close
+	case File:
+		f, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open traces file: %w", err)
+		}
+		w := newWriterExporter(f)
+		exp, closer = w, w.Close
+	case OTLP:
+		// spans do not pass OpenCensus exporters, they are bridged to OpenTelemetry SDK
+		return cfg.initOTLP(log)
+	case Jaeger:
+		j, err := jaeger.NewExporter(jaeger.Options{
+			CollectorEndpoint: cfg.Endpoint,
+			Process:           jaeger.Process{ServiceName: cfg.ServiceName},
+			OnError: func(err error) {
+				log.WithError(err).Warn("export spans")
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create jaeger exporter: %w", err)
+		}
+		exp, closer = j, func() error {
+			j.Flush()
+			return nil
+		}
+	case Zipkin:
+		r := reporterhttp.NewReporter(cfg.Endpoint,
+			reporterhttp.BatchInterval(cfg.FlushInterval),
+			reporterhttp.BatchSize(cfg.BatchSize),
+			reporterhttp.Logger(stdlog.New(logWriter{log}, "", 0)),
+		)
+		exp, closer = zipkin.NewExporter(r, &model.Endpoint{ServiceName: cfg.ServiceName}), r.Close
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+
+	trace.RegisterExporter(exp)
+	cfg.SetSampler()
+
+	return unregisterFlusher{exp: exp, closer: closer}, nil
+}
+
+// SetSampler applies sample rate, it can be changed while serving
+func (cfg Config) SetSampler() {
+	if cfg.Exporter == "" || cfg.Exporter == None {
+		return
+	}
+	if cfg.Exporter == OTLP {
+		otlpSampler.setRate(cfg.SampleRate)
+		return
+	}
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(cfg.SampleRate)})
+}
+
+type unregisterFlusher struct {
+	exp    trace.Exporter
+	closer func() error
+}
+
+func (u unregisterFlusher) Close() error {
+	trace.UnregisterExporter(u.exp)
+	return u.closer()
+}
+
+// logWriter passes messages of exporters using standard logger to service logger
+type logWriter struct {
+	log logrus.FieldLogger
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	w.log.Warn(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
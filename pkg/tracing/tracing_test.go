@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/moguchev/service/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/trace"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *recorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := &recorder{}
+	trace.RegisterExporter(rec)
+	defer trace.UnregisterExporter(rec)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+	var traceID interface{}
+
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if e, ok := logger.FromContext(r.Context()); ok {
+			traceID = e.Data[TraceIDField]
+		}
+		_, span := StartSpan(r.Context(), "usecase.GetEmployee")
+		span.End()
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/employees/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !assert.Len(t, rec.spans, 2) {
+		return
+	}
+
+	child, server := rec.spans[0], rec.spans[1]
+
+	assert.Equal(t, "GET /employees/{id}", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID.String())
+	assert.Equal(t, int64(http.StatusNotFound), server.Attributes["http.status_code"])
+	assert.Equal(t, "/employees/{id}", server.Attributes[routeAttribute])
+	assert.Equal(t, int32(trace.StatusCodeNotFound), server.Code)
+
+	assert.Equal(t, server.TraceID, child.TraceID)
+	assert.Equal(t, server.SpanID, child.ParentSpanID)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+}
+
+func TestInit_Zipkin(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		spans []map[string]interface{}
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := []map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		spans = append(spans, batch...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	flusher, err := Config{
+		Exporter:      Zipkin,
+		ServiceName:   "service",
+		SampleRate:    1,
+		Endpoint:      srv.URL,
+		FlushInterval: time.Hour,
+	}.Init(logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, span := StartSpan(context.Background(), "usecase.GetEmployees")
+		span.End()
+	}
+
+	// spans are sent on close
+	if err = flusher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "usecase.GetEmployees", spans[0]["name"])
+		assert.Equal(t, "service", spans[0]["localEndpoint"].(map[string]interface{})["serviceName"])
+	}
+}
+
+func TestInit_OTLP(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies [][]byte
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	flusher, err := Config{
+		Exporter:      OTLP,
+		ServiceName:   "service",
+		SampleRate:    1,
+		Endpoint:      srv.URL + "/v1/traces",
+		FlushInterval: time.Hour,
+		BatchSize:     512,
+	}.Init(logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := StartSpan(context.Background(), "usecase.GetEmployees")
+	_, child := StartSpan(ctx, "repository.GetEmployees")
+	child.End()
+	span.End()
+
+	// spans are sent on close
+	if err = flusher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if assert.Len(t, bodies, 1) {
+		// protobuf keeps strings as is
+		assert.True(t, bytes.Contains(bodies[0], []byte("usecase.GetEmployees")))
+		assert.True(t, bytes.Contains(bodies[0], []byte("repository.GetEmployees")))
+		assert.True(t, bytes.Contains(bodies[0], []byte("service")))
+	}
+}
+
+func TestInit_ErrorsLogged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	log, hook := test.NewNullLogger()
+
+	flusher, err := Config{
+		Exporter:    Jaeger,
+		ServiceName: "service",
+		SampleRate:  1,
+		Endpoint:    srv.URL,
+	}.Init(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := StartSpan(context.Background(), "usecase.GetEmployees")
+	span.End()
+
+	if err = flusher.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry := hook.LastEntry(); assert.NotNil(t, entry) {
+		assert.Equal(t, "tracing", entry.Data["actor"])
+		assert.Equal(t, Jaeger, entry.Data["exporter"])
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"net/http"
+)
+
+// ResponseWriter - captures status code and body size of response
+type ResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+// NewResponseWriter wraps w, status is 200 until WriteHeader is called
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns response status code
+func (rw *ResponseWriter) Status() int {
+	return rw.status
+}
+
+// Size returns number of written body bytes
+func (rw *ResponseWriter) Size() int {
+	return rw.size
+}
+
+// WriteHeader implements http.ResponseWriter
+func (rw *ResponseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher if underlying writer does
+func (rw *ResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns underlying writer for http.ResponseController
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// UnmatchedRoute - route template of requests which did not match any route
+const UnmatchedRoute = "unmatched"
+
+type routeKey struct{}
+
+// WrapRouter applies middlewares to router itself, unlike router.Use which applies them
+// to matched routes only, so that they see every request including 404, 405 and CORS
+// preflight. The first middleware is the outermost one. Route template is resolved
+// before middlewares are called, so that RouteTemplate works in them.
+func WrapRouter(router *mux.Router, mws ...mux.MiddlewareFunc) http.Handler {
+	var handler http.Handler = router
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := UnmatchedRoute
+
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.MatchErr == nil && match.Route != nil {
+			if tpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+	})
+}
+
+// RouteTemplate returns path template of matched mux route, or def if request was not routed
+func RouteTemplate(r *http.Request, def string) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+
+	if route, ok := r.Context().Value(routeKey{}).(string); ok {
+		return route
+	}
+
+	return def
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapRouter(t *testing.T) {
+	var routes []string
+	record := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			routes = append(routes, RouteTemplate(r, r.URL.Path))
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/employees/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+	handler := WrapRouter(router, record)
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/api/employees/42", http.StatusOK},
+		{http.MethodPost, "/api/employees/42", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/unknown", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.status, res.Code, tt.method+" "+tt.path)
+	}
+
+	assert.Equal(t, []string{"/api/employees/{id}", UnmatchedRoute, UnmatchedRoute}, routes)
+}